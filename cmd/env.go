@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envList reads a comma-separated environment variable, falling back to def
+// when it is unset or empty.
+func envList(key string, def []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	return envParse(key, def, time.ParseDuration)
+}
+
+func envInt64(key string, def int64) int64 {
+	return envParse(key, def, func(v string) (int64, error) {
+		return strconv.ParseInt(v, 10, 64)
+	})
+}
+
+func envBool(key string, def bool) bool {
+	return envParse(key, def, strconv.ParseBool)
+}
+
+func envFloat(key string, def float64) float64 {
+	return envParse(key, def, func(v string) (float64, error) {
+		return strconv.ParseFloat(v, 64)
+	})
+}
+
+// envParse exits the process when a variable is set but malformed, so that a
+// typo does not silently fall back to the default.
+func envParse[T any](key string, def T, parse func(string) (T, error)) T {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	out, err := parse(v)
+	if err != nil {
+		fmt.Printf("Error: invalid %s: %v\n", key, err)
+		os.Exit(1)
+	}
+	return out
+}
@@ -34,6 +34,93 @@ func main() {
 		port = "4001"
 	}
 
+	passthroughParams := envList("PASSTHROUGH_PARAMS", service.DefaultPassthroughParams)
+
+	urlPolicy := service.URLPolicy{
+		AllowedSchemes: envList("ALLOWED_URL_SCHEMES", service.DefaultURLPolicy.AllowedSchemes),
+		AllowedDomains: envList("ALLOWED_URL_DOMAINS", nil),
+	}
+
+	trustedProxies, err := transport.ParseTrustedProxies(envList("TRUSTED_PROXIES", transport.DefaultTrustedProxies))
+	if err != nil {
+		fmt.Println("Error: invalid TRUSTED_PROXIES:", err)
+		os.Exit(1)
+	}
+
+	fallbackURL := os.Getenv("FALLBACK_URL")
+	if fallbackURL != "" {
+		if err := urlPolicy.Validate(fallbackURL); err != nil {
+			fmt.Println("Error: invalid FALLBACK_URL:", err)
+			os.Exit(1)
+		}
+	}
+
+	challengeConfig := service.ChallengeConfig{
+		Enabled:    envBool("CHALLENGE_ENABLED", service.DefaultChallengeConfig.Enabled),
+		Secret:     []byte(os.Getenv("CHALLENGE_SECRET")),
+		TTL:        envDuration("CHALLENGE_TTL", service.DefaultChallengeConfig.TTL),
+		Difficulty: int(envInt64("CHALLENGE_DIFFICULTY", int64(service.DefaultChallengeConfig.Difficulty))),
+	}
+	if challengeConfig.Enabled && len(challengeConfig.Secret) == 0 {
+		fmt.Println("Error: CHALLENGE_SECRET is required when CHALLENGE_ENABLED is set")
+		os.Exit(1)
+	}
+	if challengeConfig.Difficulty < 0 || challengeConfig.Difficulty > 32 {
+		fmt.Println("Error: CHALLENGE_DIFFICULTY must be between 0 and 32")
+		os.Exit(1)
+	}
+
+	ipRangeConfig := service.IPRangeConfig{
+		DatacenterFiles: envList("IP_RANGES_DATACENTER", nil),
+		TorFiles:        envList("IP_RANGES_TOR", nil),
+		ScannerFiles:    envList("IP_RANGES_EMAIL_SCANNER", nil),
+		ASNDatabases:    envList("IP_ASN_DATABASES", nil),
+		VPNASNFile:      os.Getenv("IP_VPN_ASNS"),
+		ReloadInterval:  envDuration("IP_RANGES_RELOAD", service.DefaultIPRangeConfig.ReloadInterval),
+	}
+	ipRanges, err := service.LoadIPRanges(ipRangeConfig)
+	if err != nil {
+		fmt.Println("failed to load IP ranges:", err)
+		os.Exit(1)
+	}
+
+	botConfig := service.BotConfig{
+		CacheTTL:      envDuration("BOT_DNS_CACHE_TTL", service.DefaultBotConfig.CacheTTL),
+		LookupTimeout: envDuration("BOT_DNS_TIMEOUT", service.DefaultBotConfig.LookupTimeout),
+		WaitTimeout:   envDuration("BOT_DNS_WAIT", service.DefaultBotConfig.WaitTimeout),
+	}
+	if path := os.Getenv("BOT_DNS_FILE"); path != "" {
+		resolver, err := service.LoadStaticResolver(path)
+		if err != nil {
+			fmt.Println("failed to load BOT_DNS_FILE:", err)
+			os.Exit(1)
+		}
+		botConfig.Resolver = resolver
+	}
+
+	pages, err := service.LoadPages(os.Getenv("TEMPLATE_DIR"))
+	if err != nil {
+		fmt.Println("failed to load page templates:", err)
+		os.Exit(1)
+	}
+
+	qualityConfig := service.QualityConfig{
+		Interval:         envDuration("QUALITY_INTERVAL", service.DefaultQualityConfig.Interval),
+		Window:           envDuration("QUALITY_WINDOW", service.DefaultQualityConfig.Window),
+		MinClicks:        envInt64("QUALITY_MIN_CLICKS", service.DefaultQualityConfig.MinClicks),
+		MaxFraudRate:     envFloat("QUALITY_MAX_FRAUD_RATE", service.DefaultQualityConfig.MaxFraudRate),
+		MaxDuplicateRate: envFloat("QUALITY_MAX_DUPLICATE_RATE", service.DefaultQualityConfig.MaxDuplicateRate),
+		MinScore:         envFloat("QUALITY_MIN_SCORE", service.DefaultQualityConfig.MinScore),
+	}
+
+	autoBlockConfig := service.AutoBlockConfig{
+		Interval:        envDuration("AUTOBLOCK_INTERVAL", service.DefaultAutoBlockConfig.Interval),
+		Window:          envDuration("AUTOBLOCK_WINDOW", service.DefaultAutoBlockConfig.Window),
+		DeviceMinClicks: envInt64("AUTOBLOCK_DEVICE_MIN_CLICKS", service.DefaultAutoBlockConfig.DeviceMinClicks),
+		IPMinTrips:      envInt64("AUTOBLOCK_IP_MIN_TRIPS", service.DefaultAutoBlockConfig.IPMinTrips),
+		TTL:             envDuration("AUTOBLOCK_TTL", service.DefaultAutoBlockConfig.TTL),
+	}
+
 	ctx := context.Background()
 	fmt.Println("Connecting to database...")
 	dbPool, err := pgxpool.New(ctx, dbURL)
@@ -48,13 +135,85 @@ func main() {
 	logger = log.With(logger, "ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller)
 	logger = level.NewFilter(logger, level.AllowInfo())
 
+	var notifier service.Notifier = service.NewLogNotifier(logger)
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		notifier = service.NewWebhookNotifier(url)
+	}
+
 	queries := db.New(dbPool)
-	clickService := service.NewClickService(queries)
+	outcomes := service.NewOutcomeCounter(queries, envDuration("OUTCOME_FLUSH_INTERVAL", service.DefaultOutcomeFlushInterval))
+	fraudChecker := service.NewFraudChecker(queries, service.FraudConfig{
+		IPRanges: ipRanges,
+		DeviceReset: service.DeviceResetConfig{
+			Window:    envDuration("DEVICE_RESET_WINDOW", service.DefaultDeviceResetConfig.Window),
+			Threshold: int(envInt64("DEVICE_RESET_THRESHOLD", int64(service.DefaultDeviceResetConfig.Threshold))),
+		},
+		Velocity: service.VelocityConfig{
+			MinuteLimit:  int(envInt64("VELOCITY_MINUTE_LIMIT", int64(service.DefaultVelocityConfig.MinuteLimit))),
+			HourLimit:    int(envInt64("VELOCITY_HOUR_LIMIT", int64(service.DefaultVelocityConfig.HourLimit))),
+			DayLimit:     int(envInt64("VELOCITY_DAY_LIMIT", int64(service.DefaultVelocityConfig.DayLimit))),
+			MaxCampaigns: int(envInt64("VELOCITY_MAX_CAMPAIGNS", int64(service.DefaultVelocityConfig.MaxCampaigns))),
+		},
+		Fingerprint: service.FingerprintConfig{
+			Window:        envDuration("FINGERPRINT_WINDOW", service.DefaultFingerprintConfig.Window),
+			MaxIdentities: envInt64("FINGERPRINT_MAX_IDENTITIES", service.DefaultFingerprintConfig.MaxIdentities),
+		},
+	})
+	clickService := service.NewClickService(dbPool, queries, service.ClickConfig{
+		PassthroughParams: passthroughParams,
+		FallbackURL:       fallbackURL,
+		Pages:             pages,
+		URLPolicy:         urlPolicy,
+		Challenge:         challengeConfig,
+		Fraud:             fraudChecker,
+		Bots:              botConfig,
+		Scanner: service.ScannerConfig{
+			Window:      envDuration("SCANNER_WINDOW", service.DefaultScannerConfig.Window),
+			FanOutLinks: int(envInt64("SCANNER_FAN_OUT_LINKS", int64(service.DefaultScannerConfig.FanOutLinks))),
+		},
+		Outcomes: outcomes,
+	})
+	campaignService := service.NewCampaignService(queries, urlPolicy, os.Getenv("TRACKING_BASE_URL"), passthroughParams)
+	reportService := service.NewReportService(queries, passthroughParams)
+	publisherService := service.NewPublisherService(queries)
+	billingService := service.NewBillingService(dbPool, queries)
 	trackEndpoint := endpoints.MakeTrackEndpoint(clickService, logger)
 	endpointSet := endpoints.TrackEndpointSet{
-		TrackEndpoint: trackEndpoint,
+		TrackEndpoint:  trackEndpoint,
+		VerifyEndpoint: endpoints.MakeVerifyEndpoint(clickService),
 	}
-	handler := transport.NewHTTPHandler(endpointSet)
+	adminEndpointSet := endpoints.AdminEndpointSet{
+		ClickReportEndpoint:        endpoints.MakeClickReportEndpoint(reportService),
+		OutcomeReportEndpoint:      endpoints.MakeOutcomeReportEndpoint(reportService),
+		CreateCampaignEndpoint:     endpoints.MakeCreateCampaignEndpoint(campaignService),
+		SignLinkEndpoint:           endpoints.MakeSignLinkEndpoint(campaignService),
+		LinkQRCodeEndpoint:         endpoints.MakeLinkQRCodeEndpoint(campaignService),
+		CreatePublisherEndpoint:    endpoints.MakeCreatePublisherEndpoint(publisherService),
+		ListPublishersEndpoint:     endpoints.MakeListPublishersEndpoint(publisherService),
+		CreateTrackingLinkEndpoint: endpoints.MakeCreateTrackingLinkEndpoint(publisherService),
+		ListTrackingLinksEndpoint:  endpoints.MakeListTrackingLinksEndpoint(publisherService),
+
+		SetPublisherStatusEndpoint:    endpoints.MakeSetPublisherStatusEndpoint(publisherService),
+		SetTrackingLinkStatusEndpoint: endpoints.MakeSetTrackingLinkStatusEndpoint(publisherService),
+		SetTrackingLinkSlugEndpoint:   endpoints.MakeSetTrackingLinkSlugEndpoint(publisherService),
+		ListQualityScoresEndpoint:     endpoints.MakeListQualityScoresEndpoint(publisherService),
+
+		RescoreClickEndpoint: endpoints.MakeRescoreClickEndpoint(billingService),
+		InvoiceEndpoint:      endpoints.MakeInvoiceEndpoint(billingService),
+
+		CreateAdvertiserEndpoint:      endpoints.MakeCreateAdvertiserEndpoint(billingService),
+		ListAdvertisersEndpoint:       endpoints.MakeListAdvertisersEndpoint(billingService),
+		SetCampaignAdvertiserEndpoint: endpoints.MakeSetCampaignAdvertiserEndpoint(campaignService),
+	}
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	if adminToken == "" {
+		fmt.Println("ADMIN_TOKEN is not set; all /admin requests will be refused")
+	}
+	handler := transport.NewHTTPHandler(endpointSet, adminEndpointSet, transport.Config{
+		PassthroughParams: passthroughParams,
+		AdminToken:        adminToken,
+		TrustedProxies:    trustedProxies,
+	})
 
 	server := &http.Server{
 		Addr:         ":" + port,
@@ -64,6 +223,14 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	jobCtx, stopJobs := context.WithCancel(ctx)
+	defer stopJobs()
+	go service.NewQualityJob(queries, qualityConfig, notifier).Run(jobCtx)
+	go service.NewAutoBlocklistJob(queries, autoBlockConfig).Run(jobCtx)
+	go ipRanges.Run(jobCtx)
+	go outcomes.Run(jobCtx)
+	go fraudChecker.Run(jobCtx)
+
 	go func() {
 		fmt.Printf("Server starting on port %s...\n", port)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -79,8 +246,12 @@ func main() {
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 	fmt.Println("\nShutting down server...")
+	stopJobs()
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	server.Shutdown(shutdownCtx)
-}
\ No newline at end of file
+	if err := outcomes.Flush(shutdownCtx); err != nil {
+		fmt.Println("Error counting outcomes:", err)
+	}
+}
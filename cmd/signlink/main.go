@@ -0,0 +1,52 @@
+// Command signlink prints a signed tracking URL without going through the
+// admin API, e.g.
+//
+//	signlink -secret "$SECRET" -link 6a864502-... -ttl 24h user_id=42 sub1=email
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"project/internal/service"
+)
+
+func main() {
+	secret := flag.String("secret", os.Getenv("SIGNING_SECRET"), "campaign signing secret (default $SIGNING_SECRET)")
+	linkID := flag.String("link", "", "tracking link ID")
+	base := flag.String("base", "", "tracking base URL, e.g. https://track.example.com")
+	signed := flag.String("signed", "user_id", "comma-separated signed params, as configured on the campaign")
+	ttl := flag.Duration("ttl", 0, "link lifetime; 0 for no expiry")
+	flag.Parse()
+
+	if *secret == "" || *linkID == "" {
+		fmt.Fprintln(os.Stderr, "usage: signlink -secret SECRET -link LINK_ID [-base URL] [-signed PARAMS] [-ttl DURATION] [name=value ...]")
+		os.Exit(2)
+	}
+
+	params := make(map[string]string)
+	query := url.Values{}
+	for _, arg := range flag.Args() {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "invalid param %q, want name=value\n", arg)
+			os.Exit(2)
+		}
+		params[name] = value
+		query.Set(name, value)
+	}
+
+	var expires string
+	if *ttl > 0 {
+		expires = strconv.FormatInt(time.Now().Add(*ttl).Unix(), 10)
+		query.Set("exp", expires)
+	}
+
+	query.Set("sig", service.LinkSignature(*secret, *linkID, strings.Split(*signed, ","), params, expires))
+	fmt.Println(strings.TrimSuffix(*base, "/") + "/track/" + *linkID + "?" + query.Encode())
+}
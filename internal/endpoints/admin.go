@@ -0,0 +1,27 @@
+package endpoints
+
+import "github.com/go-kit/kit/endpoint"
+
+type AdminEndpointSet struct {
+	ClickReportEndpoint        endpoint.Endpoint
+	OutcomeReportEndpoint      endpoint.Endpoint
+	CreateCampaignEndpoint     endpoint.Endpoint
+	SignLinkEndpoint           endpoint.Endpoint
+	LinkQRCodeEndpoint         endpoint.Endpoint
+	CreatePublisherEndpoint    endpoint.Endpoint
+	ListPublishersEndpoint     endpoint.Endpoint
+	CreateTrackingLinkEndpoint endpoint.Endpoint
+	ListTrackingLinksEndpoint  endpoint.Endpoint
+
+	SetPublisherStatusEndpoint    endpoint.Endpoint
+	SetTrackingLinkStatusEndpoint endpoint.Endpoint
+	SetTrackingLinkSlugEndpoint   endpoint.Endpoint
+	ListQualityScoresEndpoint     endpoint.Endpoint
+
+	RescoreClickEndpoint endpoint.Endpoint
+	InvoiceEndpoint      endpoint.Endpoint
+
+	CreateAdvertiserEndpoint      endpoint.Endpoint
+	ListAdvertisersEndpoint       endpoint.Endpoint
+	SetCampaignAdvertiserEndpoint endpoint.Endpoint
+}
@@ -0,0 +1,70 @@
+package endpoints
+
+import (
+	"context"
+
+	"project/internal/service"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type RescoreClickRequest struct {
+	ClickID string `json:"-"`
+	Reason  string `json:"reason"`
+}
+
+type InvoiceRequest struct {
+	AdvertiserID string
+	Month        string
+}
+
+type CreateAdvertiserRequest struct {
+	Name string `json:"name"`
+}
+
+type ListAdvertisersResponse struct {
+	Advertisers []service.Advertiser `json:"advertisers"`
+}
+
+type SetCampaignAdvertiserRequest struct {
+	CampaignID   string `json:"-"`
+	AdvertiserID string `json:"advertiser_id"`
+}
+
+func MakeRescoreClickEndpoint(s service.BillingService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(RescoreClickRequest)
+		return s.RescoreClick(ctx, service.RescoreInput(req))
+	}
+}
+
+func MakeInvoiceEndpoint(s service.BillingService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(InvoiceRequest)
+		return s.Invoice(ctx, req.AdvertiserID, req.Month)
+	}
+}
+
+func MakeCreateAdvertiserEndpoint(s service.BillingService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(CreateAdvertiserRequest)
+		return s.CreateAdvertiser(ctx, service.AdvertiserInput(req))
+	}
+}
+
+func MakeListAdvertisersEndpoint(s service.BillingService) endpoint.Endpoint {
+	return func(ctx context.Context, _ any) (any, error) {
+		advertisers, err := s.ListAdvertisers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return ListAdvertisersResponse{Advertisers: advertisers}, nil
+	}
+}
+
+func MakeSetCampaignAdvertiserEndpoint(s service.CampaignService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(SetCampaignAdvertiserRequest)
+		return s.SetCampaignAdvertiser(ctx, service.CampaignAdvertiserInput(req))
+	}
+}
@@ -0,0 +1,75 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"project/internal/service"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type CreateCampaignRequest struct {
+	AdvertiserID           string          `json:"advertiser_id"`
+	Name                   string          `json:"name"`
+	StartDate              time.Time       `json:"start_date"`
+	EndDate                time.Time       `json:"end_date"`
+	Status                 string          `json:"status"`
+	TargetURL              string          `json:"target_url"`
+	FallbackURL            string          `json:"fallback_url"`
+	Timezone               string          `json:"timezone"`
+	Schedule               json.RawMessage `json:"schedule"`
+	DailyCap               *int64          `json:"daily_cap"`
+	TotalCap               *int64          `json:"total_cap"`
+	CapAction              string          `json:"cap_action"`
+	FrequencyCap           *int64          `json:"frequency_cap"`
+	FrequencyWindowSeconds int64           `json:"frequency_window_seconds"`
+	DedupWindowSeconds     int64           `json:"dedup_window_seconds"`
+	CpcMicros              int64           `json:"cpc_micros"`
+	BudgetMicros           *int64          `json:"budget_micros"`
+	RedirectMode           string          `json:"redirect_mode"`
+	SignLinks              bool            `json:"sign_links"`
+	SigningSecret          string          `json:"signing_secret"`
+	SignedParams           []string        `json:"signed_params"`
+	SignatureAction        string          `json:"signature_action"`
+	TargetPlatform         string          `json:"target_platform"`
+	AllowedReferrerDomains []string        `json:"allowed_referrer_domains"`
+	BlockedReferrerDomains []string        `json:"blocked_referrer_domains"`
+	EmptyReferrer          string          `json:"empty_referrer"`
+}
+
+type SignLinkRequest struct {
+	LinkID     string            `json:"-"`
+	Params     map[string]string `json:"params"`
+	TTLSeconds int64             `json:"ttl_seconds"`
+}
+
+type LinkQRCodeRequest struct {
+	LinkID string
+	Params map[string]string
+	Format string
+	Size   int
+	ECC    string
+}
+
+func MakeCreateCampaignEndpoint(s service.CampaignService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(CreateCampaignRequest)
+		return s.CreateCampaign(ctx, service.CampaignInput(req))
+	}
+}
+
+func MakeSignLinkEndpoint(s service.CampaignService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(SignLinkRequest)
+		return s.SignLink(ctx, service.SignLinkInput(req))
+	}
+}
+
+func MakeLinkQRCodeEndpoint(s service.CampaignService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(LinkQRCodeRequest)
+		return s.LinkQRCode(ctx, service.QRCodeInput(req))
+	}
+}
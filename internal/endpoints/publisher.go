@@ -0,0 +1,122 @@
+package endpoints
+
+import (
+	"context"
+
+	"project/internal/service"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type CreatePublisherRequest struct {
+	Name        string   `json:"name"`
+	SiteDomains []string `json:"site_domains"`
+}
+
+type ListPublishersResponse struct {
+	Publishers []service.Publisher `json:"publishers"`
+}
+
+type CreateTrackingLinkRequest struct {
+	CampaignID   string `json:"campaign_id"`
+	PublisherID  string `json:"publisher_id"`
+	DailyCap     *int64 `json:"daily_cap"`
+	TotalCap     *int64 `json:"total_cap"`
+	PayoutMicros int64  `json:"payout_micros"`
+	CpcMicros    *int64 `json:"cpc_micros"`
+	Slug         string `json:"slug"`
+}
+
+type ListTrackingLinksRequest struct {
+	CampaignID string
+}
+
+type ListTrackingLinksResponse struct {
+	Links []service.TrackingLink `json:"links"`
+}
+
+func MakeCreatePublisherEndpoint(s service.PublisherService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(CreatePublisherRequest)
+		return s.CreatePublisher(ctx, service.PublisherInput(req))
+	}
+}
+
+func MakeListPublishersEndpoint(s service.PublisherService) endpoint.Endpoint {
+	return func(ctx context.Context, _ any) (any, error) {
+		publishers, err := s.ListPublishers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return ListPublishersResponse{Publishers: publishers}, nil
+	}
+}
+
+func MakeCreateTrackingLinkEndpoint(s service.PublisherService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(CreateTrackingLinkRequest)
+		return s.CreateTrackingLink(ctx, service.TrackingLinkInput(req))
+	}
+}
+
+func MakeListTrackingLinksEndpoint(s service.PublisherService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(ListTrackingLinksRequest)
+		links, err := s.ListTrackingLinks(ctx, req.CampaignID)
+		if err != nil {
+			return nil, err
+		}
+		return ListTrackingLinksResponse{Links: links}, nil
+	}
+}
+
+type SetStatusRequest struct {
+	ID              string `json:"-"`
+	Status          string `json:"status"`
+	QualityOverride bool   `json:"quality_override"`
+}
+
+type SetSlugRequest struct {
+	LinkID string `json:"-"`
+	Slug   string `json:"slug"`
+}
+
+type ListQualityScoresRequest struct {
+	Scope string
+}
+
+type ListQualityScoresResponse struct {
+	Scores []service.QualityScore `json:"scores"`
+}
+
+func MakeSetPublisherStatusEndpoint(s service.PublisherService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(SetStatusRequest)
+		return s.SetPublisherStatus(ctx, service.StatusInput(req))
+	}
+}
+
+func MakeSetTrackingLinkStatusEndpoint(s service.PublisherService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(SetStatusRequest)
+		return s.SetTrackingLinkStatus(ctx, service.StatusInput(req))
+	}
+}
+
+func MakeSetTrackingLinkSlugEndpoint(s service.PublisherService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(SetSlugRequest)
+		return s.SetTrackingLinkSlug(ctx, service.SlugInput(req))
+	}
+}
+
+func MakeListQualityScoresEndpoint(s service.PublisherService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(ListQualityScoresRequest)
+		scores, err := s.ListQualityScores(ctx, req.Scope)
+		if err != nil {
+			return nil, err
+		}
+		return ListQualityScoresResponse{Scores: scores}, nil
+	}
+}
@@ -0,0 +1,57 @@
+package endpoints
+
+import (
+	"context"
+	"time"
+
+	"project/internal/service"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type ClickReportRequest struct {
+	CampaignID string
+	Dimension  string
+	From       time.Time
+	To         time.Time
+}
+
+type ClickReportResponse struct {
+	Rows []service.ReportRow `json:"rows"`
+}
+
+func MakeClickReportEndpoint(s service.ReportService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(ClickReportRequest)
+
+		rows, err := s.ClickReport(ctx, service.ReportInput(req))
+		if err != nil {
+			return nil, err
+		}
+
+		return ClickReportResponse{Rows: rows}, nil
+	}
+}
+
+type OutcomeReportRequest struct {
+	CampaignID string
+	From       time.Time
+	To         time.Time
+}
+
+type OutcomeReportResponse struct {
+	Rows []service.OutcomeRow `json:"rows"`
+}
+
+func MakeOutcomeReportEndpoint(s service.ReportService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(OutcomeReportRequest)
+
+		rows, err := s.OutcomeReport(ctx, service.OutcomeReportInput(req))
+		if err != nil {
+			return nil, err
+		}
+
+		return OutcomeReportResponse{Rows: rows}, nil
+	}
+}
@@ -2,6 +2,7 @@ package endpoints
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"project/internal/service"
@@ -11,23 +12,46 @@ import (
 )
 
 type TrackRequest struct {
-	LinkID    string
-	UserID    string
-	GAID      string
-	IDFA      string
-	IP        string
-	UserAgent string
-	Referrer  string
+	LinkID      string
+	UserID      string
+	GAID        string
+	IDFA        string
+	IP          string
+	UserAgent   string
+	Referrer    string
+	Passthrough map[string]string
+	// Signature and Expires are the sig and exp query parameters.
+	Signature string
+	Expires   string
+	// ForwardedChain holds the raw proxy headers IP was resolved from.
+	ForwardedChain string
+	// Headers are the request headers, read by the automation checks.
+	Headers http.Header
+	// Fingerprint is the ClickFingerprint of the request.
+	Fingerprint string
 }
 
 type TrackResponse struct {
-	StatusCode  int
-	Body        string
-	RedirectURL string
+	StatusCode   int
+	Body         string
+	RedirectURL  string
+	RedirectMode string
+	Reason       string
+}
+
+type VerifyRequest struct {
+	LinkID string `json:"-"`
+	Token  string `json:"token"`
+	Nonce  string `json:"nonce"`
+}
+
+type VerifyResponse struct {
+	RedirectURL string `json:"redirect_url,omitempty"`
 }
 
 type TrackEndpointSet struct {
-	TrackEndpoint endpoint.Endpoint
+	TrackEndpoint  endpoint.Endpoint
+	VerifyEndpoint endpoint.Endpoint
 }
 
 func MakeTrackEndpoint(s service.ClickService, logger log.Logger) endpoint.Endpoint {
@@ -40,15 +64,30 @@ func MakeTrackEndpoint(s service.ClickService, logger log.Logger) endpoint.Endpo
 		}
 
 		return TrackResponse{
-			StatusCode:  out.StatusCode,
-			Body:        out.Body,
-			RedirectURL: out.RedirectURL,
+			StatusCode:   out.StatusCode,
+			Body:         out.Body,
+			RedirectURL:  out.RedirectURL,
+			RedirectMode: out.RedirectMode,
+			Reason:       out.Reason,
 		}, nil
 	}
 
 	return LoggingMiddleware(logger)(ep)
 }
 
+func MakeVerifyEndpoint(s service.ClickService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(VerifyRequest)
+
+		out, err := s.VerifyChallenge(ctx, service.VerifyInput(req))
+		if err != nil {
+			return nil, err
+		}
+
+		return VerifyResponse{RedirectURL: out.RedirectURL}, nil
+	}
+}
+
 func LoggingMiddleware(logger log.Logger) endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, request any) (any, error) {
@@ -76,6 +115,8 @@ func LoggingMiddleware(logger log.Logger) endpoint.Middleware {
 				"link_id", req.LinkID,
 				"user_id", req.UserID,
 				"status_code", resp.StatusCode,
+				"reason", resp.Reason,
+				"redirect_mode", resp.RedirectMode,
 				"duration_ms", duration.Milliseconds(),
 				"msg", "request completed",
 			)
@@ -83,4 +124,4 @@ func LoggingMiddleware(logger log.Logger) endpoint.Middleware {
 			return response, nil
 		}
 	}
-}
\ No newline at end of file
+}
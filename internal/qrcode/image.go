@@ -0,0 +1,69 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// QuietZone is the light border, in modules, that scanners need around the
+// symbol.
+const QuietZone = 4
+
+// Scale returns the whole number of pixels per module that fits the symbol
+// and its quiet zone in size pixels, and at least 1.
+func (c *Code) Scale(size int) int {
+	return max(1, size/(c.Size+2*QuietZone))
+}
+
+// PNG renders the symbol as a greyscale PNG at most size pixels wide (larger
+// only when size is too small for one pixel per module).
+func (c *Code) PNG(size int) ([]byte, error) {
+	scale := c.Scale(size)
+	width := (c.Size + 2*QuietZone) * scale
+
+	img := image.NewGray(image.Rect(0, 0, width, width))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Dark(x, y) {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray((x+QuietZone)*scale+dx, (y+QuietZone)*scale+dy, color.Gray{})
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders the symbol as a single path in a viewBox measured in modules,
+// displayed size pixels wide.
+func (c *Code) SVG(size int) []byte {
+	dim := c.Size + 2*QuietZone
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, dim, dim)
+	buf.WriteString(`<rect width="100%" height="100%" fill="#FFFFFF"/><path fill="#000000" d="`)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Dark(x, y) {
+				fmt.Fprintf(&buf, "M%d,%dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
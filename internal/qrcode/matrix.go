@@ -0,0 +1,297 @@
+package qrcode
+
+// builder lays out the module matrix. isFunction marks finder, timing,
+// alignment, format and version modules, which masking leaves alone.
+type builder struct {
+	version    int
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+func newBuilder(version int) *builder {
+	size := version*4 + 17
+	b := &builder{version: version, size: size}
+	b.modules = make([][]bool, size)
+	b.isFunction = make([][]bool, size)
+	for i := range b.modules {
+		b.modules[i] = make([]bool, size)
+		b.isFunction[i] = make([]bool, size)
+	}
+	return b
+}
+
+func (b *builder) setFunction(x, y int, dark bool) {
+	b.modules[y][x] = dark
+	b.isFunction[y][x] = true
+}
+
+func (b *builder) drawFunctionPatterns(level Level) {
+	for i := 0; i < b.size; i++ {
+		b.setFunction(6, i, i%2 == 0)
+		b.setFunction(i, 6, i%2 == 0)
+	}
+
+	b.drawFinderPattern(3, 3)
+	b.drawFinderPattern(b.size-4, 3)
+	b.drawFinderPattern(3, b.size-4)
+
+	pos := b.alignmentPatternPositions()
+	n := len(pos)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			// The three corners overlap the finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == n-1) || (i == n-1 && j == 0) {
+				continue
+			}
+			b.drawAlignmentPattern(pos[i], pos[j])
+		}
+	}
+
+	// Reserve the format areas; the real bits are drawn once the mask is
+	// chosen.
+	b.drawFormatBits(level, 0)
+	b.drawVersion()
+}
+
+// drawFinderPattern draws a finder pattern and its separator centred on
+// (x, y), clipped to the symbol.
+func (b *builder) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= b.size || yy < 0 || yy >= b.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			b.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (b *builder) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			b.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// alignmentPatternPositions returns the row/column centres of the alignment
+// patterns, in ascending order.
+func (b *builder) alignmentPatternPositions() []int {
+	if b.version == 1 {
+		return nil
+	}
+	numAlign := b.version/7 + 2
+	step := (b.version*4 + numAlign*2 + 1) / (numAlign*2 - 2) * 2
+	if b.version == 32 {
+		step = 26
+	}
+
+	result := make([]int, numAlign)
+	result[0] = 6
+	for i, pos := numAlign-1, b.size-7; i >= 1; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+	return result
+}
+
+// formatBits returns the 15-bit format information: level and mask
+// protected by a BCH(15,5) code and XORed with the fixed pattern.
+func formatBits(level Level, mask int) int {
+	data := level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+func (b *builder) drawFormatBits(level Level, mask int) {
+	bits := formatBits(level, mask)
+	bit := func(i int) bool { return (bits>>i)&1 != 0 }
+
+	// Around the top-left finder.
+	for i := 0; i <= 5; i++ {
+		b.setFunction(8, i, bit(i))
+	}
+	b.setFunction(8, 7, bit(6))
+	b.setFunction(8, 8, bit(7))
+	b.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		b.setFunction(14-i, 8, bit(i))
+	}
+
+	// Split between the other two finders.
+	for i := 0; i < 8; i++ {
+		b.setFunction(b.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		b.setFunction(8, b.size-15+i, bit(i))
+	}
+	b.setFunction(8, b.size-8, true) // always dark
+}
+
+// versionBits returns the 18-bit version information, BCH(18,6) coded.
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+func (b *builder) drawVersion() {
+	if b.version < 7 {
+		return
+	}
+	bits := versionBits(b.version)
+	for i := 0; i < 18; i++ {
+		dark := (bits>>i)&1 != 0
+		x, y := b.size-11+i%3, i/3
+		b.setFunction(x, y, dark)
+		b.setFunction(y, x, dark)
+	}
+}
+
+// drawCodewords places the codewords in the zigzag column pairs, right to
+// left, skipping the vertical timing column. Any remainder bits stay light.
+func (b *builder) drawCodewords(data []byte) {
+	i := 0
+	for right := b.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < b.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = b.size - 1 - vert
+				}
+				if !b.isFunction[y][x] && i < len(data)*8 {
+					b.modules[y][x] = (data[i>>3]>>(7-i&7))&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (b *builder) applyMask(mask int) {
+	for y := 0; y < b.size; y++ {
+		for x := 0; x < b.size; x++ {
+			if b.isFunction[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				b.modules[y][x] = !b.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol with the four mask evaluation rules; the mask
+// with the lowest score is used.
+func (b *builder) penalty() int {
+	result := 0
+	line := make([]bool, b.size)
+
+	for y := 0; y < b.size; y++ {
+		result += linePenalty(b.modules[y])
+	}
+	for x := 0; x < b.size; x++ {
+		for y := 0; y < b.size; y++ {
+			line[y] = b.modules[y][x]
+		}
+		result += linePenalty(line)
+	}
+
+	dark := 0
+	for y := 0; y < b.size; y++ {
+		for x := 0; x < b.size; x++ {
+			c := b.modules[y][x]
+			if c {
+				dark++
+			}
+			if x+1 < b.size && y+1 < b.size &&
+				c == b.modules[y][x+1] && c == b.modules[y+1][x] && c == b.modules[y+1][x+1] {
+				result += 3
+			}
+		}
+	}
+
+	total := b.size * b.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += k * 10
+
+	return result
+}
+
+// finderLike is the 1:1:3:1:1 pattern with four light modules on one side.
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// linePenalty applies the run-length rule and the finder-like pattern rule to
+// one row or column.
+func linePenalty(line []bool) int {
+	result := 0
+
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			result += 3 + run - 5
+		}
+		run = 1
+	}
+
+	for i := 0; i+11 <= len(line); i++ {
+		for _, pattern := range finderLike {
+			match := true
+			for j, dark := range pattern {
+				if line[i+j] != dark {
+					match = false
+					break
+				}
+			}
+			if match {
+				result += 40
+			}
+		}
+	}
+
+	return result
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
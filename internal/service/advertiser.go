@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	db "project/migrations/sqlc"
+)
+
+type AdvertiserInput struct {
+	Name string
+}
+
+type Advertiser struct {
+	AdvertiserID string    `json:"advertiser_id"`
+	Name         string    `json:"name"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CampaignAdvertiserInput moves a campaign to another advertiser.
+type CampaignAdvertiserInput struct {
+	CampaignID   string
+	AdvertiserID string
+}
+
+func (s *billingService) CreateAdvertiser(ctx context.Context, req AdvertiserInput) (Advertiser, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return Advertiser{}, fmt.Errorf("%w: name is required", ErrInvalidArgument)
+	}
+
+	a, err := s.queries.CreateAdvertiser(ctx, db.CreateAdvertiserParams{
+		AdvertiserID: uuid.New(),
+		Name:         name,
+	})
+	if err != nil {
+		return Advertiser{}, err
+	}
+
+	return toAdvertiser(a), nil
+}
+
+func (s *billingService) ListAdvertisers(ctx context.Context) ([]Advertiser, error) {
+	rows, err := s.queries.ListAdvertisers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	advertisers := make([]Advertiser, 0, len(rows))
+	for _, a := range rows {
+		advertisers = append(advertisers, toAdvertiser(a))
+	}
+
+	return advertisers, nil
+}
+
+// SetCampaignAdvertiser assigns a campaign to an advertiser. Clicks charged
+// from then on are billed to the new advertiser; ledger entries already
+// written stay on the invoices of the old one.
+func (s *campaignService) SetCampaignAdvertiser(ctx context.Context, req CampaignAdvertiserInput) (Campaign, error) {
+	campaignID, err := uuid.Parse(req.CampaignID)
+	if err != nil {
+		return Campaign{}, fmt.Errorf("%w: campaign_id must be a uuid", ErrInvalidArgument)
+	}
+
+	advertiserID, err := uuid.Parse(req.AdvertiserID)
+	if err != nil {
+		return Campaign{}, fmt.Errorf("%w: advertiser_id must be a uuid", ErrInvalidArgument)
+	}
+
+	c, err := s.queries.SetCampaignAdvertiser(ctx, db.SetCampaignAdvertiserParams{
+		CampaignID:   campaignID,
+		AdvertiserID: advertiserID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Campaign{}, fmt.Errorf("%w: campaign %s", ErrNotFound, campaignID)
+	}
+	if isForeignKeyViolation(err) {
+		return Campaign{}, fmt.Errorf("%w: advertiser does not exist", ErrInvalidArgument)
+	}
+	if err != nil {
+		return Campaign{}, err
+	}
+
+	return toCampaign(c), nil
+}
+
+func toAdvertiser(a db.Advertiser) Advertiser {
+	return Advertiser{
+		AdvertiserID: a.AdvertiserID.String(),
+		Name:         a.Name,
+		CreatedAt:    a.CreatedAt.Time,
+	}
+}
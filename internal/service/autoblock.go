@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "project/migrations/sqlc"
+)
+
+type AutoBlockConfig struct {
+	Interval        time.Duration
+	Window          time.Duration
+	DeviceMinClicks int64
+	IPMinTrips      int64
+	TTL             time.Duration
+}
+
+var DefaultAutoBlockConfig = AutoBlockConfig{
+	Interval:        5 * time.Minute,
+	Window:          time.Hour,
+	DeviceMinClicks: 300,
+	IPMinTrips:      3,
+	TTL:             7 * 24 * time.Hour,
+}
+
+// AutoBlocklistJob looks for devices and IPs abusing the tracker in recent
+// clicks and adds them to blocked_ids with source=auto and an expiry, so that
+// the blocklist checks reject them on their next click. Manual entries are
+// never overwritten.
+type AutoBlocklistJob struct {
+	queries *db.Queries
+	cfg     AutoBlockConfig
+}
+
+func NewAutoBlocklistJob(q *db.Queries, cfg AutoBlockConfig) *AutoBlocklistJob {
+	return &AutoBlocklistJob{
+		queries: q,
+		cfg:     cfg,
+	}
+}
+
+func (j *AutoBlocklistJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.RunOnce(ctx); err != nil {
+				fmt.Println("Error updating blocklist:", err)
+			}
+		}
+	}
+}
+
+func (j *AutoBlocklistJob) RunOnce(ctx context.Context) error {
+	if _, err := j.queries.DeleteExpiredBlockedIDs(ctx); err != nil {
+		return err
+	}
+
+	// Windows and expiry are computed by the database, against the same
+	// clock that stamps clicks and expires blocks.
+	window := j.cfg.Window.Seconds()
+
+	devices, err := j.queries.FindAbusiveDevices(ctx, db.FindAbusiveDevicesParams{
+		WindowSeconds: window,
+		MinClicks:     j.cfg.DeviceMinClicks,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, d := range devices {
+		reason := fmt.Sprintf("%d clicks across %d campaigns in %s", d.ClickCount, d.CampaignCount, j.cfg.Window)
+		if err := j.block(ctx, d.DeviceID, reason); err != nil {
+			return err
+		}
+	}
+
+	ips, err := j.queries.FindRateLimitedIPs(ctx, db.FindRateLimitedIPsParams{
+		WindowSeconds: window,
+		MinTrips:      j.cfg.IPMinTrips,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, ip := range ips {
+		reason := fmt.Sprintf("tripped ip_rate_limit %d times in %s", ip.TripCount, j.cfg.Window)
+		if err := j.block(ctx, ip.IpAddress, reason); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (j *AutoBlocklistJob) block(ctx context.Context, id, reason string) error {
+	return j.queries.UpsertAutoBlockedID(ctx, db.UpsertAutoBlockedIDParams{
+		ID:         id,
+		Reason:     pgtype.Text{String: reason, Valid: true},
+		TtlSeconds: j.cfg.TTL.Seconds(),
+	})
+}
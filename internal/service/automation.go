@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// automationTokens appear in the user agent (or Sec-CH-UA brands) of
+// headless browsers and browser automation frameworks.
+var automationTokens = []string{
+	"headlesschrome", "phantomjs", "selenium", "webdriver", "puppeteer",
+	"playwright", "slimerjs", "htmlunit", "nightmare", "cypress/",
+}
+
+// crawlerPattern matches crawler and scripted HTTP client user agents. "bot"
+// must be followed by a separator so device names like CUBOT do not match.
+var crawlerPattern = regexp.MustCompile(`(?i)([a-z]*bot)(?:[/\-_;)]|$)|crawler|spider|scrapy|facebookexternalhit|python-urllib|python-httpx|aiohttp|go-http-client|java/|libwww-perl|apache-httpclient|axios/|node-fetch|okhttp/|httpie/`)
+
+var chromeVersionPattern = regexp.MustCompile(`(?:Chrome|Chromium)/(\d+)`)
+
+// automationSignal is one automation heuristic: a name for fraud_check_failed
+// and a function returning what it found, or an empty string.
+type automationSignal struct {
+	name   string
+	detect func(input TrackInput) string
+}
+
+// userAgentSignals are reliable on their own: no real browser announces
+// itself as a headless browser or an HTTP library.
+var userAgentSignals = []automationSignal{
+	{"automation_ua", automationUASignal},
+	{"crawler_ua", crawlerSignal},
+}
+
+// headerSignals are heuristics that privacy extensions, in-app browsers and
+// proxies also trip, so they only count together. Header order is not among
+// them: net/http does not preserve it and proxies rewrite it anyway.
+var headerSignals = []automationSignal{
+	{"accept_header", acceptSignal},
+	{"accept_language", acceptLanguageSignal},
+	{"client_hints", clientHintsSignal},
+}
+
+// AutomationCheck looks for headless browsers and scripted clients. Every
+// signal found is listed in its reason, but it counts as a single failed
+// check, and header signals block only when at least two of them agree, so
+// header heuristics alone never make a click fraud.
+type AutomationCheck struct{}
+
+func NewAutomationCheck() *AutomationCheck {
+	return &AutomationCheck{}
+}
+
+func (c *AutomationCheck) Name() string {
+	return "automation"
+}
+
+func (c *AutomationCheck) Check(ctx context.Context, input TrackInput, clickID string) FraudCheckResult {
+	found := make([]string, 0)
+	block := false
+	for _, signal := range userAgentSignals {
+		if detail := signal.detect(input); detail != "" {
+			found = append(found, signal.name+" "+detail)
+			block = true
+		}
+	}
+	headers := 0
+	for _, signal := range headerSignals {
+		if detail := signal.detect(input); detail != "" {
+			found = append(found, signal.name+" "+detail)
+			headers++
+		}
+	}
+	if headers >= 2 {
+		block = true
+	}
+
+	if len(found) == 0 {
+		return FraudCheckResult{
+			Block:  false,
+			Reason: "automation: no signal",
+		}
+	}
+	return FraudCheckResult{
+		Block:  block,
+		Reason: "automation: " + strings.Join(found, ", "),
+	}
+}
+
+func automationUASignal(input TrackInput) string {
+	ua := strings.ToLower(input.UserAgent)
+	brands := strings.ToLower(input.Headers.Get("Sec-CH-UA"))
+	for _, token := range automationTokens {
+		if strings.Contains(ua, token) || strings.Contains(brands, token) {
+			return strings.TrimSuffix(token, "/")
+		}
+	}
+	if input.Headers.Get("X-Selenium") != "" || input.Headers.Get("X-Requested-With") == "org.openqa.selenium" {
+		return "selenium"
+	}
+	return ""
+}
+
+func crawlerSignal(input TrackInput) string {
+	m := crawlerPattern.FindStringSubmatch(input.UserAgent)
+	if m == nil {
+		return ""
+	}
+	if m[1] != "" {
+		return strings.ToLower(m[1])
+	}
+	return strings.TrimSuffix(strings.ToLower(m[0]), "/")
+}
+
+// isBrowserUA reports whether the user agent claims to be a browser, which
+// is when the header checks apply; other clients are left to the UA checks.
+func isBrowserUA(ua string) bool {
+	return strings.HasPrefix(ua, "Mozilla/")
+}
+
+// acceptSignal flags browsers whose Accept header is missing or could not
+// have come from a top-level navigation, which always accepts text/html.
+func acceptSignal(input TrackInput) string {
+	if !isBrowserUA(input.UserAgent) {
+		return ""
+	}
+	accept := input.Headers.Get("Accept")
+	switch {
+	case accept == "":
+		return "missing"
+	case !strings.Contains(accept, "text/html"):
+		return "not_a_navigation"
+	}
+	return ""
+}
+
+func acceptLanguageSignal(input TrackInput) string {
+	if !isBrowserUA(input.UserAgent) {
+		return ""
+	}
+	if input.Headers.Get("Accept-Language") == "" {
+		return "missing"
+	}
+	return ""
+}
+
+// clientHintsSignal compares the Sec-CH-UA headers with the user agent.
+// Only Chromium sends them, and only over HTTPS, which the presence of
+// Sec-Fetch-Mode stands in for; Android WebView sends none.
+func clientHintsSignal(input TrackInput) string {
+	ua := input.UserAgent
+	if !isBrowserUA(ua) {
+		return ""
+	}
+
+	h := input.Headers
+	brands := h.Get("Sec-CH-UA")
+	chromium := chromeVersionPattern.FindStringSubmatch(ua)
+
+	if brands == "" {
+		if chromium == nil || strings.Contains(ua, "; wv)") || h.Get("Sec-Fetch-Mode") == "" {
+			return ""
+		}
+		if major, _ := strconv.Atoi(chromium[1]); major >= 90 {
+			return "missing"
+		}
+		return ""
+	}
+
+	if chromium == nil {
+		return "sent_by_non_chromium_ua"
+	}
+
+	switch h.Get("Sec-CH-UA-Mobile") {
+	case "?1":
+		if !strings.Contains(ua, "Mobile") {
+			return "mobile_mismatch"
+		}
+	case "?0":
+		if strings.Contains(ua, "Mobile") {
+			return "mobile_mismatch"
+		}
+	}
+
+	if platform := strings.Trim(h.Get("Sec-CH-UA-Platform"), `"`); platform != "" {
+		uaPlatform := platformFromUA(ua)
+		// Android's "desktop site" mode sends a Linux user agent.
+		if uaPlatform == "Linux" && platform == "Android" {
+			uaPlatform = platform
+		}
+		if uaPlatform != "" && !strings.EqualFold(platform, uaPlatform) {
+			return "platform_mismatch"
+		}
+	}
+
+	return ""
+}
+
+// platformFromUA maps a user agent to its Sec-CH-UA-Platform value, or ""
+// when it cannot tell.
+func platformFromUA(ua string) string {
+	switch {
+	case strings.Contains(ua, "Android"):
+		return "Android"
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"):
+		return "iOS"
+	case strings.Contains(ua, "Windows"):
+		return "Windows"
+	case strings.Contains(ua, "CrOS"):
+		return "Chrome OS"
+	case strings.Contains(ua, "Macintosh"):
+		return "macOS"
+	case strings.Contains(ua, "Linux"):
+		return "Linux"
+	}
+	return ""
+}
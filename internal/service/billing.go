@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "project/migrations/sqlc"
+)
+
+type BillingService interface {
+	RescoreClick(ctx context.Context, req RescoreInput) (RescoreResult, error)
+	Invoice(ctx context.Context, advertiserID string, month string) (Invoice, error)
+	CreateAdvertiser(ctx context.Context, req AdvertiserInput) (Advertiser, error)
+	ListAdvertisers(ctx context.Context) ([]Advertiser, error)
+}
+
+type RescoreInput struct {
+	ClickID string
+	Reason  string
+}
+
+type RescoreResult struct {
+	ClickID        string `json:"click_id"`
+	Status         string `json:"status"`
+	CreditedMicros int64  `json:"credited_micros"`
+}
+
+type Invoice struct {
+	AdvertiserID   string        `json:"advertiser_id"`
+	AdvertiserName string        `json:"advertiser_name"`
+	Month          string        `json:"month"`
+	Lines          []InvoiceLine `json:"lines"`
+	ChargedMicros  int64         `json:"charged_micros"`
+	CreditedMicros int64         `json:"credited_micros"`
+	NetMicros      int64         `json:"net_micros"`
+}
+
+type InvoiceLine struct {
+	CampaignID     string `json:"campaign_id"`
+	ChargedClicks  int64  `json:"charged_clicks"`
+	ChargedMicros  int64  `json:"charged_micros"`
+	CreditedClicks int64  `json:"credited_clicks"`
+	CreditedMicros int64  `json:"credited_micros"`
+	NetMicros      int64  `json:"net_micros"`
+}
+
+type billingService struct {
+	pool    TxBeginner
+	queries *db.Queries
+}
+
+func NewBillingService(pool TxBeginner, q *db.Queries) BillingService {
+	return &billingService{pool: pool, queries: q}
+}
+
+// RescoreClick marks a previously allowed click as fraud and credits the
+// advertiser for it. Rescoring the same click twice is a no-op error. The
+// status change and the credit are made in one transaction, so a failure
+// leaves the click allowed and the rescore can be retried.
+func (s *billingService) RescoreClick(ctx context.Context, req RescoreInput) (RescoreResult, error) {
+	clickID, err := uuid.Parse(req.ClickID)
+	if err != nil {
+		return RescoreResult{}, fmt.Errorf("%w: click_id must be a uuid", ErrInvalidArgument)
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "manual"
+	}
+
+	result := RescoreResult{ClickID: clickID.String(), Status: string(db.ClickStatusFraud)}
+
+	err = inTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		n, err := q.MarkClickFraud(ctx, db.MarkClickFraudParams{
+			Reason:  "rescored: " + reason,
+			ClickID: clickID,
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: no allowed click %s", ErrNotFound, clickID)
+		}
+
+		// Charges are written in the same transaction as their click, so
+		// an allowed click without one was never billed.
+		charge, err := q.GetLedgerCharge(ctx, clickID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		credited, err := q.InsertLedgerEntry(ctx, db.InsertLedgerEntryParams{
+			EntryID:      uuid.New(),
+			ClickID:      clickID,
+			CampaignID:   charge.CampaignID,
+			AdvertiserID: charge.AdvertiserID,
+			EntryType:    db.LedgerEntryTypeCredit,
+			AmountMicros: -charge.AmountMicros,
+		})
+		if err != nil {
+			return err
+		}
+
+		if credited > 0 {
+			err = q.RefundCampaignBudget(ctx, db.RefundCampaignBudgetParams{
+				AmountMicros: charge.AmountMicros,
+				CampaignID:   charge.CampaignID,
+			})
+			if err != nil {
+				return err
+			}
+			result.CreditedMicros = charge.AmountMicros
+		}
+		return nil
+	})
+	if err != nil {
+		return RescoreResult{}, err
+	}
+
+	return result, nil
+}
+
+// Invoice summarises the ledger for one advertiser over a calendar month
+// (YYYY-MM, UTC). Credits count towards the month they were issued in.
+func (s *billingService) Invoice(ctx context.Context, advertiserID string, month string) (Invoice, error) {
+	id, err := uuid.Parse(advertiserID)
+	if err != nil {
+		return Invoice{}, fmt.Errorf("%w: advertiser_id must be a uuid", ErrInvalidArgument)
+	}
+
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return Invoice{}, fmt.Errorf("%w: month must be YYYY-MM", ErrInvalidArgument)
+	}
+
+	advertiser, err := s.queries.GetAdvertiser(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Invoice{}, fmt.Errorf("%w: advertiser %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	rows, err := s.queries.InvoiceSummary(ctx, db.InvoiceSummaryParams{
+		AdvertiserID: id,
+		FromTime:     pgtype.Timestamp{Time: start, Valid: true},
+		ToTime:       pgtype.Timestamp{Time: start.AddDate(0, 1, 0), Valid: true},
+	})
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	invoice := Invoice{
+		AdvertiserID:   id.String(),
+		AdvertiserName: advertiser.Name,
+		Month:          start.Format("2006-01"),
+		Lines:          make([]InvoiceLine, 0, len(rows)),
+	}
+	for _, row := range rows {
+		invoice.Lines = append(invoice.Lines, InvoiceLine{
+			CampaignID:     row.CampaignID.String(),
+			ChargedClicks:  row.ChargedClicks,
+			ChargedMicros:  row.ChargedMicros,
+			CreditedClicks: row.CreditedClicks,
+			CreditedMicros: row.CreditedMicros,
+			NetMicros:      row.NetMicros,
+		})
+		invoice.ChargedMicros += row.ChargedMicros
+		invoice.CreditedMicros += row.CreditedMicros
+		invoice.NetMicros += row.NetMicros
+	}
+
+	return invoice, nil
+}
+
+// clickPrice is the link's CPC when it overrides the campaign's.
+func clickPrice(link db.ResolveTrackingLinkRow) int64 {
+	if link.TrackingLink.CpcMicros.Valid {
+		return link.TrackingLink.CpcMicros.Int64
+	}
+	return link.Campaign.CpcMicros
+}
+
+// chargeBudget reserves amount against the campaign budget. It returns false
+// when the remaining budget cannot cover the click. Either way the campaign
+// is paused once it can no longer afford another click.
+func (s *clickService) chargeBudget(ctx context.Context, campaignID uuid.UUID, amount int64) (bool, error) {
+	row, err := s.campaigns.ChargeCampaignBudget(ctx, db.ChargeCampaignBudgetParams{
+		AmountMicros: amount,
+		CampaignID:   campaignID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		go s.pauseCampaign(campaignID)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if row.BudgetMicros.Valid && row.BudgetMicros.Int64-row.SpentMicros < amount {
+		go s.pauseCampaign(campaignID)
+	}
+	return true, nil
+}
+
+func (s *clickService) pauseCampaign(campaignID uuid.UUID) {
+	if err := s.campaigns.PauseCampaign(context.Background(), campaignID); err != nil {
+		fmt.Println("Error pausing campaign:", err)
+	}
+}
+
+// chargeEntry is the ledger charge for a billed click.
+func chargeEntry(rec clickRecord) db.InsertLedgerEntryParams {
+	return db.InsertLedgerEntryParams{
+		EntryID:      uuid.New(),
+		ClickID:      rec.clickID,
+		CampaignID:   rec.campaignID,
+		AdvertiserID: rec.advertiserID,
+		EntryType:    db.LedgerEntryTypeCharge,
+		AmountMicros: rec.chargeMicros,
+	}
+}
+
+// refundCharge returns a reserved charge to the budget when the click it was
+// reserved for could not be stored.
+func (s *clickService) refundCharge(ctx context.Context, rec clickRecord) {
+	err := s.campaigns.RefundCampaignBudget(ctx, db.RefundCampaignBudgetParams{
+		AmountMicros: rec.chargeMicros,
+		CampaignID:   rec.campaignID,
+	})
+	if err != nil {
+		fmt.Println("Error refunding click charge:", err)
+	}
+}
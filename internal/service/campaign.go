@@ -0,0 +1,420 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "project/migrations/sqlc"
+)
+
+type CampaignService interface {
+	CreateCampaign(ctx context.Context, req CampaignInput) (Campaign, error)
+	SignLink(ctx context.Context, req SignLinkInput) (SignedLink, error)
+	LinkQRCode(ctx context.Context, req QRCodeInput) (QRCode, error)
+	SetCampaignAdvertiser(ctx context.Context, req CampaignAdvertiserInput) (Campaign, error)
+}
+
+// CampaignInput creates a campaign. StartDate and EndDate are wall-clock
+// times in Timezone. Optional fields left at their zero value take the
+// column defaults.
+type CampaignInput struct {
+	AdvertiserID           string
+	Name                   string
+	StartDate              time.Time
+	EndDate                time.Time
+	Status                 string
+	TargetURL              string
+	FallbackURL            string
+	Timezone               string
+	Schedule               json.RawMessage
+	DailyCap               *int64
+	TotalCap               *int64
+	CapAction              string
+	FrequencyCap           *int64
+	FrequencyWindowSeconds int64
+	DedupWindowSeconds     int64
+	CpcMicros              int64
+	BudgetMicros           *int64
+	RedirectMode           string
+	// SignLinks requires signed tracking links, generating a SigningSecret
+	// if none is given.
+	SignLinks     bool
+	SigningSecret string
+	// SignedParams are the params the signature covers: user_id, gaid,
+	// idfa or a passthrough param. It defaults to user_id.
+	SignedParams    []string
+	SignatureAction string
+	// TargetPlatform is any, android or ios; clicks from another platform
+	// fail the platform check.
+	TargetPlatform string
+	// AllowedReferrerDomains and BlockedReferrerDomains are checked against
+	// the click's referrer, together with the publisher's site domains.
+	// EmptyReferrer is allow or flag.
+	AllowedReferrerDomains []string
+	BlockedReferrerDomains []string
+	EmptyReferrer          string
+}
+
+type Campaign struct {
+	CampaignID             string          `json:"campaign_id"`
+	AdvertiserID           string          `json:"advertiser_id"`
+	Name                   string          `json:"name"`
+	StartDate              time.Time       `json:"start_date"`
+	EndDate                time.Time       `json:"end_date"`
+	Status                 string          `json:"status"`
+	TargetURL              string          `json:"target_url"`
+	FallbackURL            string          `json:"fallback_url,omitempty"`
+	Timezone               string          `json:"timezone"`
+	Schedule               json.RawMessage `json:"schedule"`
+	DailyCap               *int64          `json:"daily_cap"`
+	TotalCap               *int64          `json:"total_cap"`
+	CapAction              string          `json:"cap_action"`
+	FrequencyCap           *int64          `json:"frequency_cap"`
+	FrequencyWindowSeconds int64           `json:"frequency_window_seconds"`
+	DedupWindowSeconds     int64           `json:"dedup_window_seconds"`
+	CpcMicros              int64           `json:"cpc_micros"`
+	BudgetMicros           *int64          `json:"budget_micros"`
+	SpentMicros            int64           `json:"spent_micros"`
+	RedirectMode           string          `json:"redirect_mode"`
+	SigningSecret          string          `json:"signing_secret,omitempty"`
+	SignedParams           []string        `json:"signed_params"`
+	SignatureAction        string          `json:"signature_action"`
+	TargetPlatform         string          `json:"target_platform"`
+	AllowedReferrerDomains []string        `json:"allowed_referrer_domains"`
+	BlockedReferrerDomains []string        `json:"blocked_referrer_domains"`
+	EmptyReferrer          string          `json:"empty_referrer"`
+}
+
+// SignLinkInput asks for a signed tracking URL. LinkID is the link's UUID or
+// slug. Params are added to the URL; those in the campaign's signed_params
+// are covered by the signature. A positive TTLSeconds adds an exp parameter.
+type SignLinkInput struct {
+	LinkID     string
+	Params     map[string]string
+	TTLSeconds int64
+}
+
+type SignedLink struct {
+	URL       string     `json:"url"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type campaignService struct {
+	queries   *db.Queries
+	urlPolicy URLPolicy
+	// trackingBaseURL prefixes signed links; they are relative when empty.
+	// QR codes require it.
+	trackingBaseURL string
+	// signableParams are the request params a click is captured with, the
+	// only ones signed_params can name.
+	signableParams []string
+}
+
+func NewCampaignService(q *db.Queries, urlPolicy URLPolicy, trackingBaseURL string, passthroughParams []string) CampaignService {
+	return &campaignService{
+		queries:         q,
+		urlPolicy:       urlPolicy,
+		trackingBaseURL: strings.TrimSuffix(trackingBaseURL, "/"),
+		signableParams:  append([]string{"user_id", "gaid", "idfa"}, passthroughParams...),
+	}
+}
+
+func (s *campaignService) CreateCampaign(ctx context.Context, req CampaignInput) (Campaign, error) {
+	advertiserID, err := uuid.Parse(req.AdvertiserID)
+	if err != nil {
+		return Campaign{}, fmt.Errorf("%w: advertiser_id must be a uuid", ErrInvalidArgument)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return Campaign{}, fmt.Errorf("%w: name is required", ErrInvalidArgument)
+	}
+
+	if req.StartDate.IsZero() || !req.StartDate.Before(req.EndDate) {
+		return Campaign{}, fmt.Errorf("%w: start_date must be before end_date", ErrInvalidArgument)
+	}
+
+	status := db.CampaignStatus(req.Status)
+	if req.Status == "" {
+		status = db.CampaignStatusActive
+	}
+	if status != db.CampaignStatusActive && status != db.CampaignStatusPaused {
+		return Campaign{}, fmt.Errorf("%w: status must be active or paused", ErrInvalidArgument)
+	}
+
+	if err := s.urlPolicy.ValidateTemplate(req.TargetURL); err != nil {
+		return Campaign{}, fmt.Errorf("%w: target_url: %v", ErrInvalidArgument, err)
+	}
+
+	var fallbackURL pgtype.Text
+	if req.FallbackURL != "" {
+		if err := s.urlPolicy.ValidateTemplate(req.FallbackURL); err != nil {
+			return Campaign{}, fmt.Errorf("%w: fallback_url: %v", ErrInvalidArgument, err)
+		}
+		fallbackURL = pgtype.Text{String: req.FallbackURL, Valid: true}
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return Campaign{}, fmt.Errorf("%w: unknown timezone %q", ErrInvalidArgument, timezone)
+	}
+
+	schedule := req.Schedule
+	if len(schedule) == 0 {
+		schedule = json.RawMessage("[]")
+	}
+	if err := validateSchedule(schedule); err != nil {
+		return Campaign{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	capAction := db.CapAction(req.CapAction)
+	if req.CapAction == "" {
+		capAction = db.CapActionUnavailable
+	}
+	switch capAction {
+	case db.CapActionUnavailable, db.CapActionFallback, db.CapActionPause:
+	default:
+		return Campaign{}, fmt.Errorf("%w: cap_action must be unavailable, fallback or pause", ErrInvalidArgument)
+	}
+
+	for _, v := range []*int64{req.DailyCap, req.TotalCap, req.FrequencyCap} {
+		if v != nil && *v <= 0 {
+			return Campaign{}, fmt.Errorf("%w: caps must be positive", ErrInvalidArgument)
+		}
+	}
+
+	frequencyWindow := req.FrequencyWindowSeconds
+	if frequencyWindow == 0 {
+		frequencyWindow = 86400
+	}
+	dedupWindow := req.DedupWindowSeconds
+	if dedupWindow == 0 {
+		dedupWindow = 86400
+	}
+	if frequencyWindow < 0 || dedupWindow < 0 {
+		return Campaign{}, fmt.Errorf("%w: windows must be positive", ErrInvalidArgument)
+	}
+
+	redirectMode := db.RedirectMode(req.RedirectMode)
+	if req.RedirectMode == "" {
+		redirectMode = db.RedirectModeHttp302
+	}
+	switch redirectMode {
+	case db.RedirectModeHttp301, db.RedirectModeHttp302, db.RedirectModeHttp307,
+		db.RedirectModeMetaRefresh, db.RedirectModeJavascript, db.RedirectModeInterstitial:
+	default:
+		return Campaign{}, fmt.Errorf("%w: unknown redirect_mode %q", ErrInvalidArgument, req.RedirectMode)
+	}
+
+	var signingSecret pgtype.Text
+	if req.SignLinks || req.SigningSecret != "" {
+		secret := req.SigningSecret
+		if secret == "" {
+			secret = base64.RawURLEncoding.EncodeToString(randomSecret())
+		}
+		signingSecret = pgtype.Text{String: secret, Valid: true}
+	}
+
+	signedParams := req.SignedParams
+	if signedParams == nil {
+		signedParams = []string{"user_id"}
+	}
+	for _, name := range signedParams {
+		if name == "sig" || name == "exp" || !slices.Contains(s.signableParams, name) {
+			return Campaign{}, fmt.Errorf("%w: invalid signed param %q", ErrInvalidArgument, name)
+		}
+	}
+
+	signatureAction := db.SignatureAction(req.SignatureAction)
+	if req.SignatureAction == "" {
+		signatureAction = db.SignatureActionReject
+	}
+	if signatureAction != db.SignatureActionReject && signatureAction != db.SignatureActionFlag {
+		return Campaign{}, fmt.Errorf("%w: signature_action must be reject or flag", ErrInvalidArgument)
+	}
+
+	targetPlatform := db.TargetPlatform(req.TargetPlatform)
+	if req.TargetPlatform == "" {
+		targetPlatform = db.TargetPlatformAny
+	}
+	switch targetPlatform {
+	case db.TargetPlatformAny, db.TargetPlatformAndroid, db.TargetPlatformIos:
+	default:
+		return Campaign{}, fmt.Errorf("%w: target_platform must be any, android or ios", ErrInvalidArgument)
+	}
+
+	allowedReferrers, err := normalizeDomains("allowed_referrer_domains", req.AllowedReferrerDomains)
+	if err != nil {
+		return Campaign{}, err
+	}
+	blockedReferrers, err := normalizeDomains("blocked_referrer_domains", req.BlockedReferrerDomains)
+	if err != nil {
+		return Campaign{}, err
+	}
+
+	emptyReferrer := db.EmptyReferrerPolicy(req.EmptyReferrer)
+	if req.EmptyReferrer == "" {
+		emptyReferrer = db.EmptyReferrerPolicyAllow
+	}
+	if emptyReferrer != db.EmptyReferrerPolicyAllow && emptyReferrer != db.EmptyReferrerPolicyFlag {
+		return Campaign{}, fmt.Errorf("%w: empty_referrer must be allow or flag", ErrInvalidArgument)
+	}
+
+	if req.CpcMicros < 0 || (req.BudgetMicros != nil && *req.BudgetMicros < 0) {
+		return Campaign{}, fmt.Errorf("%w: cpc_micros and budget_micros must not be negative", ErrInvalidArgument)
+	}
+
+	c, err := s.queries.CreateCampaign(ctx, db.CreateCampaignParams{
+		CampaignID:             uuid.New(),
+		AdvertiserID:           advertiserID,
+		Name:                   name,
+		StartDate:              pgtype.Timestamp{Time: wallClock(req.StartDate), Valid: true},
+		EndDate:                pgtype.Timestamp{Time: wallClock(req.EndDate), Valid: true},
+		Status:                 status,
+		TargetUrl:              req.TargetURL,
+		FallbackUrl:            fallbackURL,
+		Timezone:               timezone,
+		Schedule:               schedule,
+		DailyCap:               toInt8(req.DailyCap),
+		TotalCap:               toInt8(req.TotalCap),
+		CapAction:              capAction,
+		FrequencyCap:           toInt8(req.FrequencyCap),
+		FrequencyWindowSeconds: frequencyWindow,
+		DedupWindowSeconds:     dedupWindow,
+		CpcMicros:              req.CpcMicros,
+		BudgetMicros:           toInt8(req.BudgetMicros),
+		RedirectMode:           redirectMode,
+		SigningSecret:          signingSecret,
+		SignedParams:           signedParams,
+		SignatureAction:        signatureAction,
+		TargetPlatform:         targetPlatform,
+		AllowedReferrerDomains: allowedReferrers,
+		BlockedReferrerDomains: blockedReferrers,
+		EmptyReferrer:          emptyReferrer,
+	})
+	if isForeignKeyViolation(err) {
+		return Campaign{}, fmt.Errorf("%w: advertiser does not exist", ErrInvalidArgument)
+	}
+	if err != nil {
+		return Campaign{}, err
+	}
+
+	return toCampaign(c), nil
+}
+
+func (s *campaignService) SignLink(ctx context.Context, req SignLinkInput) (SignedLink, error) {
+	if req.TTLSeconds < 0 {
+		return SignedLink{}, fmt.Errorf("%w: ttl_seconds must not be negative", ErrInvalidArgument)
+	}
+
+	linkID, err := resolveLinkID(ctx, s.queries, req.LinkID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return SignedLink{}, fmt.Errorf("%w: link %s", ErrNotFound, req.LinkID)
+	}
+	if err != nil {
+		return SignedLink{}, err
+	}
+
+	link, err := s.queries.ResolveTrackingLink(ctx, linkID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return SignedLink{}, fmt.Errorf("%w: link %s", ErrNotFound, linkID)
+	}
+	if err != nil {
+		return SignedLink{}, err
+	}
+
+	if !link.Campaign.SigningSecret.Valid {
+		return SignedLink{}, fmt.Errorf("%w: campaign does not sign links", ErrInvalidArgument)
+	}
+
+	var out SignedLink
+	out.URL, out.ExpiresAt = s.trackingURL(link, req.Params, time.Duration(req.TTLSeconds)*time.Second)
+
+	return out, nil
+}
+
+// trackingURL builds the public URL of a link with the given query params,
+// preferring its slug. When the campaign signs links the URL carries a sig
+// parameter, and a positive ttl adds exp.
+func (s *campaignService) trackingURL(link db.ResolveTrackingLinkRow, params map[string]string, ttl time.Duration) (string, *time.Time) {
+	query := make(url.Values, len(params)+2)
+	for name, value := range params {
+		query.Set(name, value)
+	}
+
+	linkID := link.TrackingLink.LinkID.String()
+	campaign := link.Campaign
+
+	var expiresAt *time.Time
+	if campaign.SigningSecret.Valid {
+		var expires string
+		if ttl > 0 {
+			t := time.Now().Add(ttl).Truncate(time.Second)
+			expires = strconv.FormatInt(t.Unix(), 10)
+			query.Set("exp", expires)
+			expiresAt = &t
+		}
+		query.Set("sig", LinkSignature(campaign.SigningSecret.String, linkID, campaign.SignedParams, params, expires))
+	}
+
+	// The signature always covers the link UUID, so the slug form of the
+	// URL verifies the same way.
+	ref := linkID
+	if link.TrackingLink.Slug.Valid {
+		ref = link.TrackingLink.Slug.String
+	}
+	return s.trackingBaseURL + "/track/" + ref + "?" + query.Encode(), expiresAt
+}
+
+// wallClock drops the zone from t, keeping its clock reading, since campaign
+// dates are stored as wall-clock times in the campaign's timezone.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+func toCampaign(c db.Campaign) Campaign {
+	return Campaign{
+		CampaignID:             c.CampaignID.String(),
+		AdvertiserID:           c.AdvertiserID.String(),
+		Name:                   c.Name,
+		StartDate:              c.StartDate.Time,
+		EndDate:                c.EndDate.Time,
+		Status:                 string(c.Status),
+		TargetURL:              c.TargetUrl,
+		FallbackURL:            c.FallbackUrl.String,
+		Timezone:               c.Timezone,
+		Schedule:               c.Schedule,
+		DailyCap:               fromInt8(c.DailyCap),
+		TotalCap:               fromInt8(c.TotalCap),
+		CapAction:              string(c.CapAction),
+		FrequencyCap:           fromInt8(c.FrequencyCap),
+		FrequencyWindowSeconds: c.FrequencyWindowSeconds,
+		DedupWindowSeconds:     c.DedupWindowSeconds,
+		CpcMicros:              c.CpcMicros,
+		BudgetMicros:           fromInt8(c.BudgetMicros),
+		SpentMicros:            c.SpentMicros,
+		RedirectMode:           string(c.RedirectMode),
+		SigningSecret:          c.SigningSecret.String,
+		SignedParams:           c.SignedParams,
+		SignatureAction:        string(c.SignatureAction),
+		TargetPlatform:         string(c.TargetPlatform),
+		AllowedReferrerDomains: c.AllowedReferrerDomains,
+		BlockedReferrerDomains: c.BlockedReferrerDomains,
+		EmptyReferrer:          string(c.EmptyReferrer),
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "project/migrations/sqlc"
+)
+
+type capLimit struct {
+	scope  db.CounterScope
+	id     uuid.UUID
+	period string
+	cap    int64
+}
+
+func (l capLimit) reason() string {
+	kind := "daily"
+	if l.period == "total" {
+		kind = "total"
+	}
+	return fmt.Sprintf("cap_reached: %s %s cap of %d", l.scope, kind, l.cap)
+}
+
+// capLimits lists the caps configured on the link and its campaign. Daily
+// counters are keyed by the date of now, which callers pass in the campaign's
+// timezone so that days roll over at local midnight.
+func capLimits(link db.ResolveTrackingLinkRow, now time.Time) []capLimit {
+	day := now.Format(time.DateOnly)
+
+	var limits []capLimit
+	add := func(scope db.CounterScope, id uuid.UUID, period string, cap pgtype.Int8) {
+		if cap.Valid {
+			limits = append(limits, capLimit{scope: scope, id: id, period: period, cap: cap.Int64})
+		}
+	}
+
+	add(db.CounterScopeLink, link.TrackingLink.LinkID, day, link.TrackingLink.DailyCap)
+	add(db.CounterScopeLink, link.TrackingLink.LinkID, "total", link.TrackingLink.TotalCap)
+	add(db.CounterScopeCampaign, link.Campaign.CampaignID, day, link.Campaign.DailyCap)
+	add(db.CounterScopeCampaign, link.Campaign.CampaignID, "total", link.Campaign.TotalCap)
+
+	return limits
+}
+
+// reserveCaps counts an allowed click against every cap in limits. Each
+// counter is only incremented while it is below its cap, so concurrent clicks
+// cannot overshoot. When a counter is full the increments already made are
+// undone and the full limit is returned.
+func (s *clickService) reserveCaps(ctx context.Context, limits []capLimit) (*capLimit, error) {
+	for i, l := range limits {
+		n, err := s.campaigns.TryIncrementClickCounter(ctx, db.TryIncrementClickCounterParams{
+			Scope:    l.scope,
+			ScopeID:  l.id,
+			Period:   l.period,
+			ClickCap: l.cap,
+		})
+		if err != nil {
+			s.releaseCaps(limits[:i])
+			return nil, err
+		}
+		if n == 0 {
+			s.releaseCaps(limits[:i])
+			return &limits[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *clickService) releaseCaps(limits []capLimit) {
+	ctx := context.Background()
+	for _, l := range limits {
+		err := s.campaigns.DecrementClickCounter(ctx, db.DecrementClickCounterParams{
+			Scope:   l.scope,
+			ScopeID: l.id,
+			Period:  l.period,
+		})
+		if err != nil {
+			fmt.Println("Error releasing click cap:", err)
+		}
+	}
+}
+
+// applyCapAction pauses the capped campaign or link when the campaign is
+// configured to do so. Only total caps pause: a daily cap lifts by itself
+// when its counter period rolls over at local midnight, and a pause would
+// outlast it.
+func (s *clickService) applyCapAction(link db.ResolveTrackingLinkRow, hit capLimit) {
+	if link.Campaign.CapAction != db.CapActionPause || hit.period != "total" {
+		return
+	}
+
+	ctx := context.Background()
+	var err error
+	switch hit.scope {
+	case db.CounterScopeCampaign:
+		err = s.campaigns.PauseCampaign(ctx, hit.id)
+	case db.CounterScopeLink:
+		err = s.campaigns.PauseTrackingLink(ctx, db.PauseTrackingLinkParams{
+			LinkID:       hit.id,
+			PausedReason: pgtype.Text{String: hit.reason(), Valid: true},
+		})
+	}
+	if err != nil {
+		fmt.Println("Error pausing capped", hit.scope, ":", err)
+	}
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	db "project/migrations/sqlc"
+)
+
+// ChallengeConfig controls the JS challenge served to borderline clicks.
+type ChallengeConfig struct {
+	// Enabled turns the challenge on. It is off by default: in-app browsers
+	// and server-to-server clicks cannot run it, so every borderline click
+	// from them would be lost.
+	Enabled bool
+	// Secret signs challenge tokens and must be the same on every instance,
+	// since the answer can reach a different one than the challenge. A
+	// random secret is generated when it is empty, which only works for a
+	// single instance and invalidates outstanding tokens on restart.
+	Secret []byte
+	// TTL is how long a token can be answered.
+	TTL time.Duration
+	// Difficulty is the number of leading zero bits the proof-of-work hash
+	// must have, from 0 to 32.
+	Difficulty int
+}
+
+var DefaultChallengeConfig = ChallengeConfig{
+	Enabled:    false,
+	TTL:        30 * time.Second,
+	Difficulty: 16,
+}
+
+type VerifyInput struct {
+	LinkID string
+	Token  string
+	Nonce  string
+}
+
+// VerifyOutput is where the visitor goes after a challenge. RedirectURL is
+// empty when the click was verified but is not being redirected.
+type VerifyOutput struct {
+	RedirectURL string
+	Reason      string
+}
+
+// challengePage renders the challenge for a click already recorded as
+// 'challenged'.
+func (s *clickService) challengePage(campaign db.Campaign, linkID, clickID uuid.UUID) TrackOutput {
+	body, err := s.pages.render("challenge.html", PageData{
+		Campaign:   campaign.Name,
+		Token:      s.challenge.issue(linkID, clickID, time.Now()),
+		Difficulty: s.challenge.Difficulty,
+	})
+	if err != nil {
+		fmt.Println("Error rendering challenge page:", err)
+		body = s.pages.renderUnavailable(PageData{Campaign: campaign.Name})
+	}
+	return TrackOutput{StatusCode: 200, Body: body, Reason: ReasonChallenged}
+}
+
+// VerifyChallenge completes a challenged click. The click is admitted against
+// caps and budget only now, and the conditional status update makes a token
+// single-use. A link that was paused or left its dates or schedule while the
+// challenge was open gets the unavailable response, and the click stays
+// 'challenged'.
+func (s *clickService) VerifyChallenge(ctx context.Context, req VerifyInput) (VerifyOutput, error) {
+	linkID, err := resolveLinkID(ctx, s.campaigns, req.LinkID)
+	if err != nil {
+		return s.challengeFailed(uuid.Nil)
+	}
+
+	clickID, err := s.challenge.verify(linkID, req.Token, time.Now())
+	if err != nil || !s.challenge.solved(req.Token, req.Nonce) {
+		return s.challengeFailed(uuid.Nil)
+	}
+
+	click, err := s.campaigns.GetChallengedClick(ctx, clickID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && click.LinkID != linkID) {
+		return s.challengeFailed(uuid.Nil)
+	}
+	if err != nil {
+		return VerifyOutput{}, err
+	}
+
+	link, err := s.campaigns.ResolveTrackingLink(ctx, linkID)
+	if err != nil {
+		return VerifyOutput{}, err
+	}
+	campaign := link.Campaign
+
+	input := TrackInput{
+		LinkID: req.LinkID,
+		UserID: click.UserID,
+		GAID:   click.Gaid.String,
+		IDFA:   click.Idfa.String,
+	}
+	if err := json.Unmarshal(click.Passthrough, &input.Passthrough); err != nil {
+		fmt.Println("Error decoding click passthrough:", err)
+	}
+
+	loc, err := campaignLocation(campaign)
+	if err != nil {
+		return VerifyOutput{}, err
+	}
+	now := time.Now().In(loc)
+
+	reason := pausedReason(link)
+	if reason == "" {
+		reason = outOfDatesReason(campaign, now)
+	}
+	if reason == "" {
+		scheduled, err := inSchedule(campaign.Schedule, now)
+		if err != nil {
+			fmt.Println("Error evaluating campaign schedule:", err)
+		}
+		if !scheduled {
+			reason = ReasonOutOfSchedule
+		}
+	}
+	if reason != "" {
+		s.outcomes.Add(campaign.CampaignID, reason)
+		out := s.unavailable(&campaign, input, clickID.String(), reason)
+		return VerifyOutput{RedirectURL: out.RedirectURL, Reason: reason}, nil
+	}
+
+	adm := s.admit(ctx, link, now)
+
+	reasons := append(click.FraudCheckFailed, "challenge_passed")
+	if adm.failedReason != "" {
+		reasons = append(reasons, adm.failedReason)
+	}
+
+	// The status change and the charge are written together, like a click
+	// and its charge on insert.
+	var n int64
+	err = inTx(ctx, s.pool, s.campaigns, func(q *db.Queries) error {
+		var err error
+		n, err = q.CompleteChallenge(ctx, db.CompleteChallengeParams{
+			ClickID:          clickID,
+			Status:           adm.status,
+			FraudCheckFailed: reasons,
+		})
+		if err != nil || n == 0 || adm.chargeMicros == 0 {
+			return err
+		}
+		_, err = q.InsertLedgerEntry(ctx, chargeEntry(clickRecord{
+			clickID:      clickID,
+			campaignID:   campaign.CampaignID,
+			advertiserID: campaign.AdvertiserID,
+			chargeMicros: adm.chargeMicros,
+		}))
+		return err
+	})
+	if err != nil || n == 0 {
+		// Lost a race with another answer to the same token.
+		rec := clickRecord{campaignID: campaign.CampaignID, chargeMicros: adm.chargeMicros}
+		if adm.chargeMicros > 0 {
+			s.refundCharge(ctx, rec)
+		}
+		go s.releaseCaps(adm.limits)
+		if err != nil {
+			return VerifyOutput{}, err
+		}
+		return s.challengeFailed(campaign.CampaignID)
+	}
+
+	s.outcomes.Add(campaign.CampaignID, adm.reason)
+
+	if adm.status != db.ClickStatusAllowed {
+		out := s.rejected(campaign, input, clickID.String(), adm.status, adm.reason)
+		return VerifyOutput{RedirectURL: out.RedirectURL, Reason: adm.reason}, nil
+	}
+
+	target, _ := s.substituteMacros(campaign.TargetUrl, input, clickID.String())
+	return VerifyOutput{RedirectURL: target, Reason: adm.reason}, nil
+}
+
+func (s *clickService) challengeFailed(campaignID uuid.UUID) (VerifyOutput, error) {
+	s.outcomes.Add(campaignID, ReasonChallengeFailed)
+	return VerifyOutput{}, ErrChallengeFailed
+}
+
+// issue returns a token of the form "<click_id>.<expiry>.<signature>", with
+// the signature also covering the link ID.
+func (c ChallengeConfig) issue(linkID, clickID uuid.UUID, now time.Time) string {
+	payload := clickID.String() + "." + strconv.FormatInt(now.Add(c.TTL).Unix(), 10)
+	return payload + "." + c.sign(linkID, payload)
+}
+
+func (c ChallengeConfig) sign(linkID uuid.UUID, payload string) string {
+	mac := hmac.New(sha256.New, c.Secret)
+	mac.Write([]byte(linkID.String() + "." + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks a token's signature and expiry and returns its click ID.
+func (c ChallengeConfig) verify(linkID uuid.UUID, token string, now time.Time) (uuid.UUID, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return uuid.Nil, errors.New("malformed token")
+	}
+	payload, sig := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(c.sign(linkID, payload))) {
+		return uuid.Nil, errors.New("bad token signature")
+	}
+
+	clickPart, expiryPart, ok := strings.Cut(payload, ".")
+	if !ok {
+		return uuid.Nil, errors.New("malformed token")
+	}
+	expiry, err := strconv.ParseInt(expiryPart, 10, 64)
+	if err != nil {
+		return uuid.Nil, errors.New("malformed token")
+	}
+	if now.Unix() > expiry {
+		return uuid.Nil, errors.New("token expired")
+	}
+
+	return uuid.Parse(clickPart)
+}
+
+// solved reports whether SHA-256("<token>:<nonce>") starts with Difficulty
+// zero bits.
+func (c ChallengeConfig) solved(token, nonce string) bool {
+	sum := sha256.Sum256([]byte(token + ":" + nonce))
+	return bits.LeadingZeros32(binary.BigEndian.Uint32(sum[:4])) >= c.Difficulty
+}
+
+func randomSecret() []byte {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("generating challenge secret: %v", err))
+	}
+	return b
+}
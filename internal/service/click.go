@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -14,78 +17,217 @@ import (
 
 type ClickService interface {
 	HandleClick(ctx context.Context, req TrackInput) (TrackOutput, error)
+	VerifyChallenge(ctx context.Context, req VerifyInput) (VerifyOutput, error)
 }
 
 type TrackInput struct {
-	LinkID    string
-	UserID    string
-	GAID      string
-	IDFA      string
-	IP        string
-	UserAgent string
-	Referrer  string
+	LinkID      string
+	UserID      string
+	GAID        string
+	IDFA        string
+	IP          string
+	UserAgent   string
+	Referrer    string
+	Passthrough map[string]string
+	// Signature and Expires are the sig and exp query parameters.
+	Signature string
+	Expires   string
+	// ForwardedChain holds the raw proxy headers IP was resolved from.
+	ForwardedChain string
+	// Headers are the request headers, read by the automation checks.
+	Headers http.Header
+	// Fingerprint is the ClickFingerprint of the request.
+	Fingerprint string
 }
 
 type TrackOutput struct {
-	StatusCode int
-	Body       string
+	StatusCode  int
+	Body        string
 	RedirectURL string
+	// RedirectMode is one of the Redirect constants when RedirectURL is set.
+	RedirectMode string
+	// Reason is the internal outcome code (one of the Reason constants).
+	Reason string
+}
+
+// DefaultPassthroughParams are the publisher query parameters captured on
+// each click when PASSTHROUGH_PARAMS is not configured.
+var DefaultPassthroughParams = []string{
+	"sub1", "sub2", "sub3", "sub4", "sub5",
+	"source", "placement", "creative",
+}
+
+type ClickConfig struct {
+	// PassthroughParams lists the publisher query parameters available as
+	// macros in target URLs.
+	PassthroughParams []string
+	// FallbackURL is where traffic goes when a click is not redirected to
+	// the campaign and the campaign has no fallback URL of its own.
+	FallbackURL string
+	// Pages renders the HTML served when there is no fallback URL.
+	Pages *Pages
+	// URLPolicy is applied to redirect URLs after macro substitution.
+	URLPolicy URLPolicy
+	// Challenge configures the JS challenge for borderline clicks.
+	Challenge ChallengeConfig
+	// Fraud runs the fraud checks. Its Run must be started to expire the
+	// state the in-memory checks keep.
+	Fraud *FraudChecker
+	// Bots configures recognition of preview crawlers and search bots.
+	Bots BotConfig
+	// Scanner tunes the email security gateway heuristics.
+	Scanner ScannerConfig
+	// Outcomes tallies the reason code of every request. Its Run must be
+	// started for the counts to reach the database.
+	Outcomes *OutcomeCounter
 }
 
 type clickService struct {
-	campaigns    *db.Queries
-	fraudChecker *FraudChecker
+	pool              TxBeginner
+	campaigns         *db.Queries
+	fraudChecker      *FraudChecker
+	passthroughParams []string
+	fallbackURL       string
+	pages             *Pages
+	urlPolicy         URLPolicy
+	challenge         ChallengeConfig
+	bots              *botDetector
+	scanners          *scannerDetector
+	outcomes          *OutcomeCounter
+	ipRanges          *IPRanges
 }
 
-func NewClickService(c *db.Queries) ClickService {
+func NewClickService(pool TxBeginner, c *db.Queries, cfg ClickConfig) ClickService {
+	pages := cfg.Pages
+	if pages == nil {
+		pages = DefaultPages()
+	}
+	urlPolicy := cfg.URLPolicy
+	if len(urlPolicy.AllowedSchemes) == 0 {
+		urlPolicy.AllowedSchemes = DefaultURLPolicy.AllowedSchemes
+	}
+	challenge := cfg.Challenge
+	if len(challenge.Secret) == 0 {
+		challenge.Secret = randomSecret()
+	}
+	fraudChecker := cfg.Fraud
+	if fraudChecker == nil {
+		fraudChecker = NewFraudChecker(c, FraudConfig{})
+	}
+	outcomes := cfg.Outcomes
+	if outcomes == nil {
+		outcomes = NewOutcomeCounter(c, DefaultOutcomeFlushInterval)
+	}
 	return &clickService{
-		campaigns:    c,
-		fraudChecker: NewFraudChecker(c),
+		pool:              pool,
+		campaigns:         c,
+		fraudChecker:      fraudChecker,
+		passthroughParams: cfg.PassthroughParams,
+		fallbackURL:       cfg.FallbackURL,
+		pages:             pages,
+		urlPolicy:         urlPolicy,
+		challenge:         challenge,
+		bots:              newBotDetector(cfg.Bots),
+		scanners:          newScannerDetector(cfg.Scanner),
+		outcomes:          outcomes,
+		ipRanges:          fraudChecker.ipRanges,
 	}
 }
 
 func (s *clickService) HandleClick(ctx context.Context, req TrackInput) (TrackOutput, error) {
-	if req.UserID == "" {
-		return TrackOutput{StatusCode: 400, Body: "values missing"}, nil
+	out, campaignID := s.handleClick(ctx, req)
+	s.outcomes.Add(campaignID, out.Reason)
+	return out, nil
+}
+
+// handleClick returns the response along with the campaign it was counted
+// against, which is the nil uuid for unresolved links.
+func (s *clickService) handleClick(ctx context.Context, req TrackInput) (TrackOutput, uuid.UUID) {
+	// Speculative loads are turned away before anything is recorded; the
+	// browser requests the link again if it is followed.
+	if isPrefetch(req) {
+		return TrackOutput{StatusCode: 503, Body: "prefetch not supported", Reason: ReasonPrefetch}, uuid.Nil
 	}
 
-	linkID, err := uuid.Parse(req.LinkID)
-	if err != nil {
-		return TrackOutput{StatusCode: 200, Body: "<html><body>campaign not available</body></html>"}, nil
+	// Preview crawlers rarely carry the publisher's parameters, so they are
+	// recognized before those are checked.
+	if bot := s.bots.detect(ctx, req.UserAgent, req.IP); bot != nil {
+		return s.botVisit(ctx, req, bot)
+	}
+
+	// Scans of a printed QR code carry no user_id; each gets one of its own,
+	// after the signature is taken since the code was signed without one.
+	scan := req.UserID == "" && req.Passthrough["source"] == QRSource
+	if req.UserID == "" && !scan {
+		return TrackOutput{StatusCode: 400, Body: "values missing", Reason: ReasonMissingParams}, uuid.Nil
+	}
+
+	// Signatures cover the values as the publisher sent them.
+	signed := req
+	if scan {
+		req.UserID = QRSource + ":" + uuid.NewString()
 	}
+	limitAdTracking := normalizeDeviceIDs(&req)
 
-	campaign, err := s.campaigns.GetCampaignByLinkID(ctx, linkID)
+	linkID, err := resolveLinkID(ctx, s.campaigns, req.LinkID)
 	if err != nil {
-		return TrackOutput{StatusCode: 200, Body: "<html><body>campaign not available</body></html>"}, nil
+		return s.unavailable(nil, req, "", ReasonUnknownLink), uuid.Nil
 	}
 
-	if campaign.Status != db.CampaignStatusActive {
-		return TrackOutput{StatusCode: 200, Body: "<html><body>campaign not available</body></html>"}, nil
+	link, err := s.campaigns.ResolveTrackingLink(ctx, linkID)
+	if err != nil {
+		return s.unavailable(nil, req, "", ReasonUnknownLink), uuid.Nil
 	}
 
-	now := time.Now()
-	var startTime, endTime time.Time
+	campaign := link.Campaign
+	if reason := pausedReason(link); reason != "" {
+		return s.unavailable(&campaign, req, "", reason), campaign.CampaignID
+	}
 
-	if campaign.StartDate.Valid {
-		startTime = campaign.StartDate.Time
-	} else {
-		return TrackOutput{StatusCode: 200, Body: "<html><body>campaign not available</body></html>"}, nil
+	loc, err := campaignLocation(campaign)
+	if err != nil {
+		fmt.Println("Error loading campaign timezone:", err)
+		return s.unavailable(&campaign, req, "", ReasonBadTimezone), campaign.CampaignID
 	}
+	now := time.Now().In(loc)
 
-	if campaign.EndDate.Valid {
-		endTime = campaign.EndDate.Time
-	} else {
-		return TrackOutput{StatusCode: 200, Body: "<html><body>campaign not available</body></html>"}, nil
+	if reason := outOfDatesReason(campaign, now); reason != "" {
+		return s.unavailable(&campaign, req, "", reason), campaign.CampaignID
 	}
 
-	if now.Before(startTime) || now.After(endTime) {
-		return TrackOutput{StatusCode: 200, Body: "<html><body>campaign not available</body></html>"}, nil
+	signatureFailure := checkSignature(campaign, linkID.String(), signed, now)
+	if signatureFailure != "" && campaign.SignatureAction == db.SignatureActionReject {
+		return s.unavailable(&campaign, req, "", ReasonBadSignature), campaign.CampaignID
 	}
 
 	clickID := uuid.New()
 	clickIDStr := clickID.String()
 
-	fraudResults, blockCount := s.fraudChecker.RunChecks(ctx, req, clickIDStr)
+	scheduled, err := inSchedule(campaign.Schedule, now)
+	if err != nil {
+		fmt.Println("Error evaluating campaign schedule:", err)
+	}
+	if !scheduled {
+		return s.unavailable(&campaign, req, clickIDStr, ReasonOutOfSchedule), campaign.CampaignID
+	}
+
+	scanner, timing := s.detectScanner(req, linkID, now)
+	if scanner != "" {
+		go s.insertClickAsync(clickRecord{
+			clickID:         clickID,
+			linkID:          linkID,
+			campaignID:      campaign.CampaignID,
+			publisherID:     link.Publisher.PublisherID,
+			advertiserID:    campaign.AdvertiserID,
+			input:           req,
+			status:          db.ClickStatusScanner,
+			fraudReasons:    []string{"email_scanner: " + scanner},
+			limitAdTracking: limitAdTracking,
+		})
+		return s.unavailable(&campaign, req, clickIDStr, ReasonEmailScanner), campaign.CampaignID
+	}
+
+	fraudResults, blockCount := s.fraudChecker.RunChecks(withTrackingLink(ctx, link), req, clickIDStr)
 
 	failedReasons := make([]string, 0)
 	for _, result := range fraudResults {
@@ -95,33 +237,187 @@ func (s *clickService) HandleClick(ctx context.Context, req TrackInput) (TrackOu
 	}
 
 	clickStatus := db.ClickStatusAllowed
+	reason := ReasonRedirected
 	if blockCount >= 2 {
 		clickStatus = db.ClickStatusFraud
+		reason = ReasonFraud
+	}
+
+	// With signature_action 'flag' a bad signature is recorded rather than
+	// turned away unseen, but the click is never admitted: a forged request
+	// can pass the challenge as easily as a real one.
+	if signatureFailure != "" {
+		clickStatus = db.ClickStatusFraud
+		reason = ReasonBadSignature
+		failedReasons = append(failedReasons, signatureFailure)
 	}
 
 	substitutedURL, missingMacros := s.substituteMacros(campaign.TargetUrl, req, clickIDStr)
 
 	if len(missingMacros) > 0 {
+		if clickStatus == db.ClickStatusAllowed {
+			reason = ReasonMissingMacros
+		}
 		clickStatus = db.ClickStatusFraud
 		failedReasons = append(failedReasons, fmt.Sprintf("missing required macros: %s", strings.Join(missingMacros, ", ")))
 	}
 
-	go s.insertClickAsync(clickID, linkID, campaign.CampaignID, req, clickStatus, failedReasons)
+	// Macro values come from the request, so the final URL is checked
+	// again even though the template passed validation at creation.
+	if err := s.urlPolicy.Validate(substitutedURL); err != nil {
+		if clickStatus == db.ClickStatusAllowed {
+			clickStatus = db.ClickStatusError
+			reason = ReasonInvalidURL
+		}
+		failedReasons = append(failedReasons, "invalid_target_url")
+	}
+
+	// Only clicks still allowed count towards frequency caps and uniqueness,
+	// so a bot replaying a real user's IDs cannot use up that user's cap.
+	isUnique := false
+	if clickStatus == db.ClickStatusAllowed {
+		var frequencyReason string
+		isUnique, frequencyReason = s.touchIdentities(ctx, campaign, req)
+		if frequencyReason != "" {
+			clickStatus = db.ClickStatusCapped
+			reason = ReasonFrequencyCapped
+			failedReasons = append(failedReasons, frequencyReason)
+		}
+	}
+	// A repeat hit that is not a scanner is a double-tap: redirected, but
+	// never counted as a unique click.
+	if timing == "repeat_hit" {
+		isUnique = false
+	}
+
+	if clickStatus == db.ClickStatusAllowed && blockCount == 1 && s.challenge.Enabled {
+		// A single failed check is borderline: hold the click until the
+		// visitor passes the JS challenge. The insert is synchronous so the
+		// click exists before the challenge can be answered.
+		err := s.insertClick(ctx, clickRecord{
+			clickID:         clickID,
+			linkID:          linkID,
+			campaignID:      campaign.CampaignID,
+			publisherID:     link.Publisher.PublisherID,
+			advertiserID:    campaign.AdvertiserID,
+			input:           req,
+			status:          db.ClickStatusChallenged,
+			fraudReasons:    failedReasons,
+			isUnique:        isUnique,
+			limitAdTracking: limitAdTracking,
+		})
+		if err != nil {
+			return s.unavailable(&campaign, req, "", ReasonChallenged), campaign.CampaignID
+		}
+		return s.challengePage(campaign, linkID, clickID), campaign.CampaignID
+	}
+
+	var chargeMicros int64
+	var limits []capLimit
+	if clickStatus == db.ClickStatusAllowed {
+		adm := s.admit(ctx, link, now)
+		clickStatus, chargeMicros, limits = adm.status, adm.chargeMicros, adm.limits
+		if adm.reason != ReasonRedirected {
+			reason = adm.reason
+			failedReasons = append(failedReasons, adm.failedReason)
+		}
+	}
+
+	go s.insertClickAsync(clickRecord{
+		clickID:         clickID,
+		linkID:          linkID,
+		campaignID:      campaign.CampaignID,
+		publisherID:     link.Publisher.PublisherID,
+		advertiserID:    campaign.AdvertiserID,
+		input:           req,
+		status:          clickStatus,
+		fraudReasons:    failedReasons,
+		isUnique:        isUnique,
+		chargeMicros:    chargeMicros,
+		limits:          limits,
+		limitAdTracking: limitAdTracking,
+	})
+
+	if clickStatus != db.ClickStatusAllowed {
+		return s.rejected(campaign, req, clickIDStr, clickStatus, reason), campaign.CampaignID
+	}
+
+	out := s.redirectTo(substitutedURL, string(campaign.RedirectMode), PageData{Campaign: campaign.Name})
+	out.Reason = reason
+	return out, campaign.CampaignID
+}
+
+// pausedReason returns the outcome for a link whose link, publisher or
+// campaign is paused, or "" when all three are active.
+func pausedReason(link db.ResolveTrackingLinkRow) string {
+	switch {
+	case link.TrackingLink.Status != db.LinkStatusActive:
+		return ReasonLinkPaused
+	case link.Publisher.Status != db.PublisherStatusActive:
+		return ReasonPublisherPaused
+	case link.Campaign.Status != db.CampaignStatusActive:
+		return ReasonCampaignPaused
+	}
+	return ""
+}
 
-	if clickStatus == db.ClickStatusFraud {
-		return TrackOutput{
-			StatusCode: 200,
-			Body:       "<html><body>campaign not available</body></html>",
-		}, nil
+// outOfDatesReason returns the outcome for a campaign that has not started
+// or has ended at now, which is in the campaign's timezone, or "" while it
+// runs.
+func outOfDatesReason(campaign db.Campaign, now time.Time) string {
+	switch {
+	case !campaign.StartDate.Valid || now.Before(localTime(campaign.StartDate, now.Location())):
+		return ReasonNotStarted
+	case !campaign.EndDate.Valid || now.After(localTime(campaign.EndDate, now.Location())):
+		return ReasonEnded
 	}
+	return ""
+}
 
-	return TrackOutput{
-		StatusCode:  302,
-		RedirectURL: substitutedURL,
-		Body:        fmt.Sprintf(`<html><head><meta http-equiv="refresh" content="0;url=%s"></head><body>Redirecting...</body></html>`, substitutedURL),
-	}, nil
+// admission is the result of admitting a click that passed the fraud checks.
+type admission struct {
+	status       db.ClickStatus
+	reason       string
+	failedReason string
+	chargeMicros int64
+	// limits are the reserved cap counters, released if the click is
+	// dropped after admission.
+	limits []capLimit
 }
 
+// admit reserves the click against its caps and charges the campaign budget.
+func (s *clickService) admit(ctx context.Context, link db.ResolveTrackingLinkRow, now time.Time) admission {
+	limits := capLimits(link, now)
+
+	capHit, err := s.reserveCaps(ctx, limits)
+	if err != nil {
+		fmt.Println("Error reserving click caps:", err)
+	}
+	if capHit != nil {
+		go s.applyCapAction(link, *capHit)
+		return admission{status: db.ClickStatusCapped, reason: ReasonCapped, failedReason: capHit.reason()}
+	}
+
+	adm := admission{status: db.ClickStatusAllowed, reason: ReasonRedirected, limits: limits}
+	if price := clickPrice(link); price > 0 {
+		charged, err := s.chargeBudget(ctx, link.Campaign.CampaignID, price)
+		if err != nil {
+			fmt.Println("Error charging campaign budget:", err)
+		}
+		if charged {
+			adm.chargeMicros = price
+		} else if err == nil {
+			go s.releaseCaps(limits)
+			return admission{status: db.ClickStatusCapped, reason: ReasonBudgetExhausted, failedReason: "budget_exhausted"}
+		}
+	}
+
+	return adm
+}
+
+// substituteMacros expands the macros in targetURL. Values from the request
+// are query-escaped, so they cannot add parameters or a fragment to the URL
+// or expand into another macro.
 func (s *clickService) substituteMacros(targetURL string, input TrackInput, clickID string) (string, []string) {
 	missingMacros := make([]string, 0)
 	result := targetURL
@@ -130,7 +426,7 @@ func (s *clickService) substituteMacros(targetURL string, input TrackInput, clic
 		if input.UserID == "" {
 			missingMacros = append(missingMacros, "user_id")
 		} else {
-			result = strings.ReplaceAll(result, "{user_id}", input.UserID)
+			result = strings.ReplaceAll(result, "{user_id}", url.QueryEscape(input.UserID))
 		}
 	}
 
@@ -138,7 +434,7 @@ func (s *clickService) substituteMacros(targetURL string, input TrackInput, clic
 		if input.GAID == "" {
 			missingMacros = append(missingMacros, "gaid")
 		} else {
-			result = strings.ReplaceAll(result, "{gaid}", input.GAID)
+			result = strings.ReplaceAll(result, "{gaid}", url.QueryEscape(input.GAID))
 		}
 	}
 
@@ -146,24 +442,70 @@ func (s *clickService) substituteMacros(targetURL string, input TrackInput, clic
 		result = strings.ReplaceAll(result, "{click_id}", clickID)
 	}
 
+	// Passthrough params are optional, so an absent value expands to an
+	// empty string rather than being treated as a missing macro.
+	for _, name := range s.passthroughParams {
+		macro := "{" + name + "}"
+		if strings.Contains(result, macro) {
+			result = strings.ReplaceAll(result, macro, url.QueryEscape(input.Passthrough[name]))
+		}
+	}
+
 	return result, missingMacros
 }
 
-func (s *clickService) insertClickAsync(clickID uuid.UUID, linkID uuid.UUID, campaignID uuid.UUID, input TrackInput, status db.ClickStatus, fraudReasons []string) {
-	ctx := context.Background()
-	
+type clickRecord struct {
+	clickID      uuid.UUID
+	linkID       uuid.UUID
+	campaignID   uuid.UUID
+	publisherID  uuid.UUID
+	advertiserID uuid.UUID
+	input        TrackInput
+	status       db.ClickStatus
+	fraudReasons []string
+	isUnique     bool
+	chargeMicros int64
+	// limits are the cap counters reserved for the click, released if it
+	// cannot be stored.
+	limits []capLimit
+	// limitAdTracking is set when the click sent the all-zero device ID.
+	limitAdTracking bool
+}
+
+func (s *clickService) insertClickAsync(rec clickRecord) {
+	s.insertClick(context.Background(), rec)
+}
+
+func (s *clickService) insertClick(ctx context.Context, rec clickRecord) error {
+	input := rec.input
+
+	passthrough, err := json.Marshal(input.Passthrough)
+	if err != nil || input.Passthrough == nil {
+		passthrough = []byte("{}")
+	}
+
 	params := db.InsertClickParams{
-		ClickID:          clickID,
-		LinkID:           linkID,
-		CampaignID:       campaignID,
+		ClickID:          rec.clickID,
+		LinkID:           rec.linkID,
+		CampaignID:       rec.campaignID,
+		PublisherID:      rec.publisherID,
 		UserID:           input.UserID,
-		Status:           status,
-		FraudCheckFailed: fraudReasons,
+		Status:           rec.status,
+		FraudCheckFailed: rec.fraudReasons,
+		Passthrough:      passthrough,
+		IsUnique:         rec.isUnique,
+		LimitAdTracking:  rec.limitAdTracking,
 	}
 
 	if input.IP != "" {
 		params.IpAddress = pgtype.Text{String: input.IP, Valid: true}
 	}
+	if input.Fingerprint != "" {
+		params.Fingerprint = pgtype.Text{String: input.Fingerprint, Valid: true}
+	}
+	if input.ForwardedChain != "" {
+		params.ForwardedChain = pgtype.Text{String: input.ForwardedChain, Valid: true}
+	}
 	if input.UserAgent != "" {
 		params.UserAgent = pgtype.Text{String: input.UserAgent, Valid: true}
 	}
@@ -177,8 +519,26 @@ func (s *clickService) insertClickAsync(clickID uuid.UUID, linkID uuid.UUID, cam
 		params.Idfa = pgtype.Text{String: input.IDFA, Valid: true}
 	}
 
-	err := s.campaigns.InsertClick(ctx, params)
+	if rec.chargeMicros > 0 {
+		// The charge is written with the click so that a rescore, which
+		// needs the click, always finds its charge too.
+		err = inTx(ctx, s.pool, s.campaigns, func(q *db.Queries) error {
+			if err := q.InsertClick(ctx, params); err != nil {
+				return err
+			}
+			_, err := q.InsertLedgerEntry(ctx, chargeEntry(rec))
+			return err
+		})
+	} else {
+		err = s.campaigns.InsertClick(ctx, params)
+	}
 	if err != nil {
 		fmt.Println("Error inserting click:", err)
+		if rec.chargeMicros > 0 {
+			s.refundCharge(ctx, rec)
+		}
+		s.releaseCaps(rec.limits)
+		return err
 	}
-}
\ No newline at end of file
+	return nil
+}
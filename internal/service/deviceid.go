@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// zeroDeviceID is what Android (limit ad tracking) and iOS (ATT denied)
+// report in place of a real advertising ID.
+const zeroDeviceID = "00000000-0000-0000-0000-000000000000"
+
+var deviceIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// normalizeDeviceIDs lowercases the advertising IDs so they match the
+// blocklist and identities however the publisher cased them, and drops the
+// all-zero ID, which is shared by every opted-out device. It reports whether
+// an all-zero ID was sent.
+func normalizeDeviceIDs(req *TrackInput) bool {
+	limitAdTracking := false
+	for _, id := range []*string{&req.GAID, &req.IDFA} {
+		*id = strings.ToLower(strings.TrimSpace(*id))
+		if *id == zeroDeviceID {
+			*id = ""
+			limitAdTracking = true
+		}
+	}
+	return limitAdTracking
+}
+
+// DeviceIDFormatCheck blocks advertising IDs that are not UUIDs; real GAIDs
+// and IDFAs always are.
+type DeviceIDFormatCheck struct{}
+
+func NewDeviceIDFormatCheck() *DeviceIDFormatCheck {
+	return &DeviceIDFormatCheck{}
+}
+
+func (c *DeviceIDFormatCheck) Name() string {
+	return "device_id_format"
+}
+
+func (c *DeviceIDFormatCheck) Check(ctx context.Context, input TrackInput, clickID string) FraudCheckResult {
+	if input.GAID != "" && !deviceIDPattern.MatchString(input.GAID) {
+		return FraudCheckResult{
+			Block:  true,
+			Reason: "device_id_format: gaid is not a uuid",
+		}
+	}
+	if input.IDFA != "" && !deviceIDPattern.MatchString(input.IDFA) {
+		return FraudCheckResult{
+			Block:  true,
+			Reason: "device_id_format: idfa is not a uuid",
+		}
+	}
+
+	return FraudCheckResult{
+		Block:  false,
+		Reason: "device_id_format: device ids are well formed",
+	}
+}
+
+type DeviceResetConfig struct {
+	// Window is how long the IDs seen from a source are remembered.
+	Window time.Duration
+	// Threshold is the number of distinct IDs, each seen only once, that one
+	// IP and user agent may present within Window.
+	Threshold int
+}
+
+var DefaultDeviceResetConfig = DeviceResetConfig{
+	Window:    time.Hour,
+	Threshold: 10,
+}
+
+// maxDeviceSources bounds the sources DeviceIDResetCheck remembers; the
+// least recently seen one is dropped to make room.
+const maxDeviceSources = 100000
+
+// DeviceIDResetCheck flags device ID reset fraud: one source (IP and user
+// agent) presenting a stream of fresh advertising IDs, none of which ever
+// comes back, as an emulator resetting its ID before every click does.
+type DeviceIDResetCheck struct {
+	cfg DeviceResetConfig
+
+	mu      sync.Mutex
+	sources *lruCache[string, *deviceSource]
+}
+
+type deviceSource struct {
+	started  time.Time
+	lastSeen time.Time
+	// seen holds at most Threshold IDs: the check is decided by then.
+	seen map[string]int
+	// singles counts the IDs in seen that were presented exactly once.
+	singles int
+}
+
+func NewDeviceIDResetCheck(cfg DeviceResetConfig) *DeviceIDResetCheck {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultDeviceResetConfig.Window
+	}
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = DefaultDeviceResetConfig.Threshold
+	}
+	return &DeviceIDResetCheck{cfg: cfg, sources: newLRUCache[string, *deviceSource](maxDeviceSources)}
+}
+
+func (c *DeviceIDResetCheck) Name() string {
+	return "device_id_reset"
+}
+
+func (c *DeviceIDResetCheck) Check(ctx context.Context, input TrackInput, clickID string) FraudCheckResult {
+	id := input.GAID
+	if id == "" {
+		id = input.IDFA
+	}
+	if id == "" || input.IP == "" || !deviceIDPattern.MatchString(id) {
+		return FraudCheckResult{
+			Block:  false,
+			Reason: "device_id_reset: no device id provided",
+		}
+	}
+
+	if c.observe(input.IP+" "+input.UserAgent, id, time.Now()) {
+		return FraudCheckResult{
+			Block:  true,
+			Reason: "device_id_reset: source keeps presenting new device ids",
+		}
+	}
+
+	return FraudCheckResult{
+		Block:  false,
+		Reason: "device_id_reset: device ids repeat normally",
+	}
+}
+
+// observe records id for source and reports whether the source is over the
+// threshold of never-repeating IDs.
+func (c *DeviceIDResetCheck) observe(source, id string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.sources.get(source)
+	if !ok || now.Sub(s.started) > c.cfg.Window {
+		s = &deviceSource{started: now, seen: make(map[string]int)}
+		c.sources.add(source, s)
+	}
+	s.lastSeen = now
+
+	if _, ok := s.seen[id]; !ok && len(s.seen) >= c.cfg.Threshold {
+		if s.singles >= c.cfg.Threshold {
+			return true
+		}
+		// Make room by forgetting an ID that already repeated; with fewer
+		// singles than the threshold there is at least one.
+		for seenID, n := range s.seen {
+			if n > 1 {
+				delete(s.seen, seenID)
+				break
+			}
+		}
+	}
+
+	s.seen[id]++
+	switch s.seen[id] {
+	case 1:
+		s.singles++
+	case 2:
+		s.singles--
+	}
+
+	return s.singles >= c.cfg.Threshold
+}
+
+// sweep drops the sources not seen within the window, which the next click
+// from them would start over anyway.
+func (c *DeviceIDResetCheck) sweep(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sources.pruneOldest(func(s *deviceSource) bool {
+		return now.Sub(s.lastSeen) > c.cfg.Window
+	})
+}
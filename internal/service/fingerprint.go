@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "project/migrations/sqlc"
+)
+
+// fingerprintHeaders are the request headers that, with the IP, make up a
+// click fingerprint. They are set by the browser rather than the link, so
+// they stay the same while user_id and device IDs are rotated.
+var fingerprintHeaders = []string{
+	"User-Agent",
+	"Accept-Language",
+	"Accept",
+	"Accept-Encoding",
+	"Sec-CH-UA",
+	"Sec-CH-UA-Mobile",
+	"Sec-CH-UA-Platform",
+}
+
+// ClickFingerprint hashes the client IP and browser headers of a click into
+// a stable hex string. Header values are lowercased and their whitespace
+// collapsed so cosmetic differences between clients do not split it.
+func ClickFingerprint(ip string, header http.Header) string {
+	h := sha256.New()
+	h.Write([]byte(ip))
+	for _, name := range fingerprintHeaders {
+		h.Write([]byte{0})
+		h.Write([]byte(strings.ToLower(strings.Join(strings.Fields(header.Get(name)), " "))))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+type FingerprintConfig struct {
+	// Window is how far back clicks sharing a fingerprint are looked at.
+	Window time.Duration
+	// MaxIdentities is the number of distinct user_id and device ID
+	// combinations one fingerprint may claim within Window; the click that
+	// would make it one more is blocked. Households and offices behind one
+	// address share fingerprints when their browsers match, so it is set
+	// well above a family's worth of users.
+	MaxIdentities int64
+}
+
+var DefaultFingerprintConfig = FingerprintConfig{
+	Window:        10 * time.Minute,
+	MaxIdentities: 10,
+}
+
+// FingerprintBurstCheck blocks bursts of clicks that share a fingerprint
+// while claiming different identities: one browser on one address rotating
+// user_id and device IDs to pass as many users.
+type FingerprintBurstCheck struct {
+	queries *db.Queries
+	cfg     FingerprintConfig
+}
+
+func NewFingerprintBurstCheck(queries *db.Queries, cfg FingerprintConfig) *FingerprintBurstCheck {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultFingerprintConfig.Window
+	}
+	if cfg.MaxIdentities <= 0 {
+		cfg.MaxIdentities = DefaultFingerprintConfig.MaxIdentities
+	}
+	return &FingerprintBurstCheck{queries: queries, cfg: cfg}
+}
+
+func (c *FingerprintBurstCheck) Name() string {
+	return "fingerprint_burst"
+}
+
+func (c *FingerprintBurstCheck) Check(ctx context.Context, input TrackInput, clickID string) FraudCheckResult {
+	if input.Fingerprint == "" {
+		return FraudCheckResult{
+			Block:  false,
+			Reason: "fingerprint_burst: no fingerprint",
+		}
+	}
+
+	count, err := c.queries.CountFingerprintIdentities(ctx, db.CountFingerprintIdentitiesParams{
+		Fingerprint:   pgtype.Text{String: input.Fingerprint, Valid: true},
+		WindowSeconds: c.cfg.Window.Seconds(),
+		UserID:        input.UserID,
+		Gaid:          input.GAID,
+		Idfa:          input.IDFA,
+	})
+	if err != nil {
+		return FraudCheckResult{
+			Block:  false,
+			Reason: "error checking fingerprint burst",
+		}
+	}
+
+	// count leaves out the identity of this click.
+	if count >= c.cfg.MaxIdentities {
+		return FraudCheckResult{
+			Block:  true,
+			Reason: fmt.Sprintf("fingerprint_burst: %d other identities sent clicks with the same fingerprint", count),
+		}
+	}
+
+	return FraudCheckResult{
+		Block:  false,
+		Reason: "fingerprint_burst: fingerprint not shared across identities",
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 
@@ -19,32 +20,114 @@ type FraudCheck interface {
 	Name() string
 }
 
+// FraudConfig configures the fraud checks. IPRangeCheck only runs when
+// IPRanges is set.
+type FraudConfig struct {
+	IPRanges    *IPRanges
+	DeviceReset DeviceResetConfig
+	Velocity    VelocityConfig
+	Fingerprint FingerprintConfig
+}
+
+type trackingLinkContextKey struct{}
+
+// withTrackingLink makes the link being clicked, with its campaign and
+// publisher, available to checks that depend on their settings.
+func withTrackingLink(ctx context.Context, link db.ResolveTrackingLinkRow) context.Context {
+	return context.WithValue(ctx, trackingLinkContextKey{}, link)
+}
+
+func campaignFromContext(ctx context.Context) (db.Campaign, bool) {
+	link, ok := ctx.Value(trackingLinkContextKey{}).(db.ResolveTrackingLinkRow)
+	return link.Campaign, ok
+}
+
+func publisherFromContext(ctx context.Context) (db.Publisher, bool) {
+	link, ok := ctx.Value(trackingLinkContextKey{}).(db.ResolveTrackingLinkRow)
+	return link.Publisher, ok
+}
+
+// sweeper is implemented by checks that keep state in memory, which
+// FraudChecker.Run expires outside the click path.
+type sweeper interface {
+	sweep(now time.Time)
+}
+
+// overlappingChecks pairs checks that key on the same source, the client IP
+// and browser, and so tend to fire together on one burst. When both block
+// they count as one failed check.
+var overlappingChecks = [][2]string{
+	{"device_id_reset", "fingerprint_burst"},
+}
+
+// fraudSweepInterval is how often Run expires the checks' in-memory state.
+const fraudSweepInterval = time.Minute
+
 type FraudChecker struct {
-	checks []FraudCheck
+	checks   []FraudCheck
+	ipRanges *IPRanges
 }
 
-func NewFraudChecker(queries *db.Queries) *FraudChecker {
-	return &FraudChecker{
-		checks: []FraudCheck{
-			NewIPRateLimitCheck(queries),
-			NewUABlocklistCheck(queries),
-			NewDeviceIDBlocklistCheck(queries),
-		},
+func NewFraudChecker(queries *db.Queries, cfg FraudConfig) *FraudChecker {
+	checks := []FraudCheck{
+		NewIPRateLimitCheck(queries),
+		NewUABlocklistCheck(queries),
+		NewDeviceIDBlocklistCheck(queries),
+		NewIPBlocklistCheck(queries),
+		NewDeviceIDFormatCheck(),
+		NewDeviceIDResetCheck(cfg.DeviceReset),
+		NewPlatformCheck(),
+		NewVelocityCheck(cfg.Velocity),
+		NewCampaignSpreadCheck(cfg.Velocity),
+		NewFingerprintBurstCheck(queries, cfg.Fingerprint),
+		NewReferrerCheck(),
+		NewAutomationCheck(),
+	}
+	if cfg.IPRanges != nil {
+		checks = append(checks, NewIPRangeCheck(cfg.IPRanges))
+	}
+
+	return &FraudChecker{checks: checks, ipRanges: cfg.IPRanges}
+}
+
+func (fc *FraudChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(fraudSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, check := range fc.checks {
+				if s, ok := check.(sweeper); ok {
+					s.sweep(now)
+				}
+			}
+		}
 	}
 }
 
 func (fc *FraudChecker) RunChecks(ctx context.Context, input TrackInput, clickID string) ([]FraudCheckResult, int) {
 	results := make([]FraudCheckResult, 0, len(fc.checks))
+	blocked := make(map[string]bool)
 	blockCount := 0
 
 	for _, check := range fc.checks {
 		result := check.Check(ctx, input, clickID)
 		results = append(results, result)
 		if result.Block {
+			blocked[check.Name()] = true
 			blockCount++
 		}
 	}
 
+	for _, pair := range overlappingChecks {
+		if blocked[pair[0]] && blocked[pair[1]] {
+			blockCount--
+		}
+	}
+
 	return results, blockCount
 }
 
@@ -188,4 +271,45 @@ func (c *DeviceIDBlocklistCheck) Check(ctx context.Context, input TrackInput, cl
 		Block:  false,
 		Reason: "device_id_blocklist: device id not in blocklist",
 	}
-}
\ No newline at end of file
+}
+
+type IPBlocklistCheck struct {
+	queries *db.Queries
+}
+
+func NewIPBlocklistCheck(queries *db.Queries) *IPBlocklistCheck {
+	return &IPBlocklistCheck{queries: queries}
+}
+
+func (c *IPBlocklistCheck) Name() string {
+	return "ip_blocklist"
+}
+
+func (c *IPBlocklistCheck) Check(ctx context.Context, input TrackInput, clickID string) FraudCheckResult {
+	if input.IP == "" {
+		return FraudCheckResult{
+			Block:  false,
+			Reason: "ip_address not provided",
+		}
+	}
+
+	blocked, err := c.queries.IsBlocked(ctx, input.IP)
+	if err != nil {
+		return FraudCheckResult{
+			Block:  false,
+			Reason: "error checking ip blocklist",
+		}
+	}
+
+	if blocked {
+		return FraudCheckResult{
+			Block:  true,
+			Reason: "ip_blocklist: ip_address is in blocklist",
+		}
+	}
+
+	return FraudCheckResult{
+		Block:  false,
+		Reason: "ip_blocklist: ip_address not in blocklist",
+	}
+}
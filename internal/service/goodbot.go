@@ -0,0 +1,309 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "project/migrations/sqlc"
+)
+
+// knownBot is a link-preview crawler or search bot. Bots that publish
+// reverse DNS domains must pass a forward-confirmed reverse DNS check;
+// the rest are recognized by user agent alone.
+type knownBot struct {
+	name    string
+	tokens  []string // lowercase user agent substrings
+	domains []string // reverse DNS suffixes, each starting with "."
+}
+
+var knownBots = []knownBot{
+	{name: "slack", tokens: []string{"slackbot-linkexpanding", "slack-imgproxy", "slackbot"}},
+	{name: "whatsapp", tokens: []string{"whatsapp/"}},
+	{name: "facebook", tokens: []string{"facebookexternalhit", "facebot", "facebookcatalog"}},
+	{name: "twitter", tokens: []string{"twitterbot"}},
+	{name: "linkedin", tokens: []string{"linkedinbot"}},
+	{name: "discord", tokens: []string{"discordbot"}},
+	{name: "telegram", tokens: []string{"telegrambot"}},
+	{name: "skype", tokens: []string{"skypeuripreview"}},
+	{name: "pinterest", tokens: []string{"pinterestbot"}, domains: []string{".pinterest.com"}},
+	{name: "google", tokens: []string{"googlebot", "google-inspectiontool", "googleother", "adsbot-google"}, domains: []string{".googlebot.com", ".google.com", ".googleusercontent.com"}},
+	{name: "bing", tokens: []string{"bingbot", "bingpreview", "adidxbot"}, domains: []string{".search.msn.com"}},
+	{name: "apple", tokens: []string{"applebot"}, domains: []string{".applebot.apple.com"}},
+	{name: "yandex", tokens: []string{"yandexbot", "yandeximages"}, domains: []string{".yandex.ru", ".yandex.net", ".yandex.com"}},
+	{name: "baidu", tokens: []string{"baiduspider"}, domains: []string{".baidu.com", ".baidu.jp"}},
+	{name: "duckduckgo", tokens: []string{"duckduckbot"}},
+}
+
+// Resolver is the DNS used to verify bots; *net.Resolver satisfies it.
+type Resolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+type BotConfig struct {
+	// Resolver defaults to net.DefaultResolver.
+	Resolver Resolver
+	// CacheTTL is how long a verification result is reused for an address.
+	CacheTTL time.Duration
+	// LookupTimeout bounds a verification's DNS queries. They run in the
+	// background, so it does not delay requests.
+	LookupTimeout time.Duration
+	// WaitTimeout is how long a request waits for a verification that is not
+	// cached yet. A bot that is not verified in time is left to the fraud
+	// checks, and the verdict is cached for its next request.
+	WaitTimeout time.Duration
+}
+
+var DefaultBotConfig = BotConfig{
+	CacheTTL:      time.Hour,
+	LookupTimeout: 2 * time.Second,
+	WaitTimeout:   50 * time.Millisecond,
+}
+
+// maxBotCacheEntries bounds the verification cache, which evicts the least
+// recently used verdict when full.
+const maxBotCacheEntries = 10000
+
+// botDetector recognizes known bots and caches reverse DNS verdicts by bot
+// and address.
+type botDetector struct {
+	cfg BotConfig
+
+	mu    sync.Mutex
+	cache *lruCache[string, botVerdict]
+	// pending holds the verifications in flight, closed when their verdict
+	// is cached, so concurrent requests from one bot share the lookups.
+	pending map[string]chan struct{}
+}
+
+type botVerdict struct {
+	verified bool
+	expires  time.Time
+}
+
+type detectedBot struct {
+	name     string
+	verified bool
+}
+
+func newBotDetector(cfg BotConfig) *botDetector {
+	if cfg.Resolver == nil {
+		cfg.Resolver = net.DefaultResolver
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = DefaultBotConfig.CacheTTL
+	}
+	if cfg.LookupTimeout <= 0 {
+		cfg.LookupTimeout = DefaultBotConfig.LookupTimeout
+	}
+	if cfg.WaitTimeout <= 0 {
+		cfg.WaitTimeout = DefaultBotConfig.WaitTimeout
+	}
+	return &botDetector{
+		cfg:     cfg,
+		cache:   newLRUCache[string, botVerdict](maxBotCacheEntries),
+		pending: make(map[string]chan struct{}),
+	}
+}
+
+// detect returns the bot making the request, or nil. A user agent claiming
+// to be a bot with published domains whose address does not verify is an
+// impostor and is left to the fraud checks.
+func (d *botDetector) detect(ctx context.Context, userAgent, ip string) *detectedBot {
+	ua := strings.ToLower(userAgent)
+	for _, bot := range knownBots {
+		if !slices.ContainsFunc(bot.tokens, func(token string) bool { return strings.Contains(ua, token) }) {
+			continue
+		}
+		if len(bot.domains) == 0 {
+			return &detectedBot{name: bot.name}
+		}
+		if ip != "" && d.verify(ctx, bot, ip) {
+			return &detectedBot{name: bot.name, verified: true}
+		}
+		return nil
+	}
+	return nil
+}
+
+// verify reports whether ip belongs to bot. Uncached addresses are verified
+// in the background; the request waits for the verdict for at most
+// WaitTimeout and otherwise counts as unverified.
+func (d *botDetector) verify(ctx context.Context, bot knownBot, ip string) bool {
+	key := bot.name + " " + ip
+
+	d.mu.Lock()
+	v, ok := d.cache.get(key)
+	if ok && time.Now().Before(v.expires) {
+		d.mu.Unlock()
+		return v.verified
+	}
+	done, ok := d.pending[key]
+	if !ok {
+		done = make(chan struct{})
+		d.pending[key] = done
+		go d.lookup(key, bot.domains, ip, done)
+	}
+	d.mu.Unlock()
+
+	timer := time.NewTimer(d.cfg.WaitTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+
+	d.mu.Lock()
+	v, _ = d.cache.get(key)
+	d.mu.Unlock()
+	return v.verified
+}
+
+// lookup verifies ip against domains, caches the verdict under key and
+// closes done.
+func (d *botDetector) lookup(key string, domains []string, ip string, done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.cfg.LookupTimeout)
+	defer cancel()
+
+	verified := d.reverseDNS(ctx, domains, ip)
+
+	d.mu.Lock()
+	d.cache.add(key, botVerdict{verified: verified, expires: time.Now().Add(d.cfg.CacheTTL)})
+	delete(d.pending, key)
+	d.mu.Unlock()
+	close(done)
+}
+
+// reverseDNS reports whether ip has a PTR name under one of domains that
+// resolves back to ip.
+func (d *botDetector) reverseDNS(ctx context.Context, domains []string, ip string) bool {
+	names, err := d.cfg.Resolver.LookupAddr(ctx, ip)
+	if err != nil {
+		return false
+	}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if !slices.ContainsFunc(domains, func(domain string) bool { return strings.HasSuffix(name, domain) }) {
+			continue
+		}
+		addrs, err := d.cfg.Resolver.LookupHost(ctx, name)
+		if err != nil {
+			continue
+		}
+		if slices.ContainsFunc(addrs, func(addr string) bool { return sameIP(addr, ip) }) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameIP(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	return ipA != nil && ipA.Equal(ipB)
+}
+
+// StaticResolver answers lookups from a hosts-style file ("<ip> <name>..."
+// per line, "#" comments) instead of DNS, for offline environments and for
+// pinning the addresses of known crawlers.
+type StaticResolver struct {
+	names map[string][]string
+	addrs map[string][]string
+}
+
+func LoadStaticResolver(path string) (*StaticResolver, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := &StaticResolver{names: make(map[string][]string), addrs: make(map[string][]string)}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		ip := net.ParseIP(fields[0])
+		if ip == nil || len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected an address followed by names", path, n)
+		}
+		addr := ip.String()
+		for _, name := range fields[1:] {
+			name = strings.ToLower(strings.TrimSuffix(name, "."))
+			r.names[addr] = append(r.names[addr], name)
+			r.addrs[name] = append(r.addrs[name], addr)
+		}
+	}
+	return r, scanner.Err()
+}
+
+func (r *StaticResolver) LookupAddr(_ context.Context, addr string) ([]string, error) {
+	if ip := net.ParseIP(addr); ip != nil {
+		addr = ip.String()
+	}
+	if names, ok := r.names[addr]; ok {
+		return names, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+}
+
+func (r *StaticResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addrs, ok := r.addrs[strings.ToLower(strings.TrimSuffix(host, "."))]; ok {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+// botVisit answers a known bot with the campaign's preview page and records
+// the hit in bot_visits instead of creating a click.
+func (s *clickService) botVisit(ctx context.Context, req TrackInput, bot *detectedBot) (TrackOutput, uuid.UUID) {
+	linkID, err := resolveLinkID(ctx, s.campaigns, req.LinkID)
+	if err != nil {
+		return s.unavailable(nil, req, "", ReasonUnknownLink), uuid.Nil
+	}
+
+	link, err := s.campaigns.ResolveTrackingLink(ctx, linkID)
+	if err != nil {
+		return s.unavailable(nil, req, "", ReasonUnknownLink), uuid.Nil
+	}
+
+	params := db.InsertBotVisitParams{
+		VisitID:    uuid.New(),
+		LinkID:     linkID,
+		CampaignID: link.Campaign.CampaignID,
+		Bot:        bot.name,
+		Verified:   bot.verified,
+	}
+	if req.IP != "" {
+		params.IpAddress = pgtype.Text{String: req.IP, Valid: true}
+	}
+	if req.UserAgent != "" {
+		params.UserAgent = pgtype.Text{String: req.UserAgent, Valid: true}
+	}
+	go func() {
+		if err := s.campaigns.InsertBotVisit(context.Background(), params); err != nil {
+			fmt.Println("Error inserting bot visit:", err)
+		}
+	}()
+
+	return TrackOutput{
+		StatusCode: 200,
+		Body:       s.pages.renderPreview(PageData{Campaign: link.Campaign.Name}),
+		Reason:     ReasonBotVisit,
+	}, link.Campaign.CampaignID
+}
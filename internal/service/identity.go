@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	db "project/migrations/sqlc"
+)
+
+// clickIdentities lists the identities a click is attributed to, prefixed
+// with their kind so that a user id can never collide with a device id.
+func clickIdentities(input TrackInput) []string {
+	ids := []string{"user_id:" + input.UserID}
+	if input.GAID != "" {
+		ids = append(ids, "gaid:"+input.GAID)
+	}
+	if input.IDFA != "" {
+		ids = append(ids, "idfa:"+input.IDFA)
+	}
+	return ids
+}
+
+// touchIdentities records the click against each of its identities for the
+// campaign. The click is unique only if none of its identities has clicked
+// within the campaign's dedup window. A non-empty reason is returned when any
+// identity is over the campaign's frequency cap.
+func (s *clickService) touchIdentities(ctx context.Context, campaign db.Campaign, input TrackInput) (bool, string) {
+	unique := true
+	reason := ""
+
+	for _, id := range clickIdentities(input) {
+		row, err := s.campaigns.TouchCampaignIdentity(ctx, db.TouchCampaignIdentityParams{
+			CampaignID:    campaign.CampaignID,
+			Identity:      id,
+			WindowSeconds: campaign.FrequencyWindowSeconds,
+			DedupSeconds:  campaign.DedupWindowSeconds,
+		})
+		if err != nil {
+			fmt.Println("Error recording click identity:", err)
+			return false, ""
+		}
+
+		unique = unique && row.IsUnique
+
+		if reason == "" && campaign.FrequencyCap.Valid && row.WindowClicks > campaign.FrequencyCap.Int64 {
+			kind, _, _ := strings.Cut(id, ":")
+			reason = fmt.Sprintf("frequency_cap: %s clicked %d times in %ds (cap %d)",
+				kind, row.WindowClicks, campaign.FrequencyWindowSeconds, campaign.FrequencyCap.Int64)
+		}
+	}
+
+	return unique, reason
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// IP range categories, reported by IPRangeCheck.
+const (
+	IPCategoryDatacenter = "datacenter"
+	IPCategoryVPN        = "vpn"
+	IPCategoryTor        = "tor"
+	// IPCategoryEmailScanner ranges belong to email security gateways. They
+	// are handled as scanner hits rather than by IPRangeCheck.
+	IPCategoryEmailScanner = "email_scanner"
+)
+
+// IPRangeConfig lists the range files loaded into IPRanges. Datacenter, Tor
+// and email scanner files hold one address or CIDR per line ("#" starts a
+// comment; Tor exit-addresses files with "ExitAddress <ip>" lines also work).
+// ASN databases are CSV files whose first two columns are network and ASN, as
+// in the GeoLite2 ASN CSV; networks announced by an ASN in VPNASNFile (one
+// "AS1234" or "1234" per line) are categorized as VPN.
+type IPRangeConfig struct {
+	DatacenterFiles []string
+	TorFiles        []string
+	ScannerFiles    []string
+	ASNDatabases    []string
+	VPNASNFile      string
+	ReloadInterval  time.Duration
+}
+
+var DefaultIPRangeConfig = IPRangeConfig{
+	ReloadInterval: time.Hour,
+}
+
+func (c IPRangeConfig) empty() bool {
+	return len(c.DatacenterFiles) == 0 && len(c.TorFiles) == 0 && len(c.ScannerFiles) == 0 && len(c.ASNDatabases) == 0
+}
+
+// IPRanges maps addresses to a range category. Lookups read an immutable
+// trie that Reload swaps atomically, so they never wait on a reload.
+type IPRanges struct {
+	cfg  IPRangeConfig
+	trie atomic.Pointer[prefixTrie]
+}
+
+// LoadIPRanges reads the configured files once; call Run to keep them fresh.
+func LoadIPRanges(cfg IPRangeConfig) (*IPRanges, error) {
+	r := &IPRanges{cfg: cfg}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Lookup returns the category of the most specific range containing ip, or
+// an empty string.
+func (r *IPRanges) Lookup(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	return r.trie.Load().lookup(addr.Unmap())
+}
+
+// Reload rebuilds the trie from disk. On error the current ranges stay in
+// use.
+func (r *IPRanges) Reload() error {
+	t := &prefixTrie{}
+
+	// Later categories win where the same prefix is listed twice.
+	for _, path := range r.cfg.DatacenterFiles {
+		if err := loadRangeFile(t, path, IPCategoryDatacenter); err != nil {
+			return err
+		}
+	}
+	if len(r.cfg.ASNDatabases) > 0 {
+		asns, err := loadASNList(r.cfg.VPNASNFile)
+		if err != nil {
+			return err
+		}
+		for _, path := range r.cfg.ASNDatabases {
+			if err := loadASNDatabase(t, path, asns, IPCategoryVPN); err != nil {
+				return err
+			}
+		}
+	}
+	for _, path := range r.cfg.TorFiles {
+		if err := loadRangeFile(t, path, IPCategoryTor); err != nil {
+			return err
+		}
+	}
+	for _, path := range r.cfg.ScannerFiles {
+		if err := loadRangeFile(t, path, IPCategoryEmailScanner); err != nil {
+			return err
+		}
+	}
+
+	r.trie.Store(t)
+	return nil
+}
+
+func (r *IPRanges) Run(ctx context.Context) {
+	if r.cfg.empty() || r.cfg.ReloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Reload(); err != nil {
+				fmt.Println("Error reloading IP ranges:", err)
+			}
+		}
+	}
+}
+
+func loadRangeFile(t *prefixTrie, path, category string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		entry := fields[0]
+		if entry == "ExitAddress" && len(fields) > 1 {
+			entry = fields[1]
+		} else if len(fields) > 1 && !strings.ContainsAny(entry, ".:") {
+			// Other exit-addresses lines (ExitNode, Published, ...).
+			continue
+		}
+
+		prefix, err := parsePrefix(entry)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		t.insert(prefix, category)
+	}
+	return scanner.Err()
+}
+
+func loadASNList(path string) (map[uint32]bool, error) {
+	asns := make(map[uint32]bool)
+	if path == "" {
+		return asns, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		asn, err := parseASN(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		asns[asn] = true
+	}
+	return asns, scanner.Err()
+}
+
+func loadASNDatabase(t *prefixTrie, path string, asns map[uint32]bool, category string) error {
+	if len(asns) == 0 {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(bufio.NewReader(f))
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	for n := 1; ; n++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if len(record) < 2 {
+			continue
+		}
+
+		asn, err := parseASN(record[1])
+		if err != nil {
+			if n == 1 {
+				continue // header
+			}
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		if !asns[asn] {
+			continue
+		}
+
+		prefix, err := parsePrefix(record[0])
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		t.insert(prefix, category)
+	}
+}
+
+func parseASN(v string) (uint32, error) {
+	v = strings.TrimSpace(v)
+	if len(v) > 2 && strings.EqualFold(v[:2], "AS") {
+		v = v[2:]
+	}
+	asn, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ASN %q", v)
+	}
+	return uint32(asn), nil
+}
+
+// parsePrefix accepts a CIDR or a single address.
+func parsePrefix(v string) (netip.Prefix, error) {
+	if strings.Contains(v, "/") {
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		if prefix.Addr().Is4In6() {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), max(0, prefix.Bits()-96))
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(v)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// prefixTrie is a binary trie over address bits, one root per family. A
+// lookup walks at most 32 or 128 nodes and allocates nothing.
+type prefixTrie struct {
+	v4, v6 trieNode
+}
+
+type trieNode struct {
+	children [2]*trieNode
+	category string
+}
+
+func (t *prefixTrie) root(addr netip.Addr) *trieNode {
+	if addr.Is4() {
+		return &t.v4
+	}
+	return &t.v6
+}
+
+func (t *prefixTrie) insert(prefix netip.Prefix, category string) {
+	addr := prefix.Addr()
+	bytes := addr.AsSlice()
+
+	node := t.root(addr)
+	for i := 0; i < prefix.Bits(); i++ {
+		bit := bytes[i/8] >> (7 - i%8) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = &trieNode{}
+		}
+		node = node.children[bit]
+	}
+	node.category = category
+}
+
+func (t *prefixTrie) lookup(addr netip.Addr) string {
+	var bytes []byte
+	if addr.Is4() {
+		b := addr.As4()
+		bytes = b[:]
+	} else {
+		b := addr.As16()
+		bytes = b[:]
+	}
+
+	node := t.root(addr)
+	match := node.category
+	for i := 0; i < len(bytes)*8; i++ {
+		node = node.children[bytes[i/8]>>(7-i%8)&1]
+		if node == nil {
+			break
+		}
+		if node.category != "" {
+			match = node.category
+		}
+	}
+	return match
+}
+
+// IPRangeCheck blocks clicks from datacenter, VPN and Tor addresses.
+type IPRangeCheck struct {
+	ranges *IPRanges
+}
+
+func NewIPRangeCheck(ranges *IPRanges) *IPRangeCheck {
+	return &IPRangeCheck{ranges: ranges}
+}
+
+func (c *IPRangeCheck) Name() string {
+	return "ip_range"
+}
+
+func (c *IPRangeCheck) Check(ctx context.Context, input TrackInput, clickID string) FraudCheckResult {
+	if input.IP == "" {
+		return FraudCheckResult{
+			Block:  false,
+			Reason: "ip_address not provided",
+		}
+	}
+
+	if category := c.ranges.Lookup(input.IP); category != "" && category != IPCategoryEmailScanner {
+		return FraudCheckResult{
+			Block:  true,
+			Reason: "ip_range: " + category,
+		}
+	}
+
+	return FraudCheckResult{
+		Block:  false,
+		Reason: "ip_range: ip_address not in a listed range",
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import "container/list"
+
+// lruCache is a map holding at most size entries that evicts the least
+// recently used one to make room. It is not safe for concurrent use.
+type lruCache[K comparable, V any] struct {
+	size  int
+	order *list.List // front is the most recently used
+	items map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func newLRUCache[K comparable, V any](size int) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		size:  size,
+		order: list.New(),
+		items: make(map[K]*list.Element),
+	}
+}
+
+// get returns the value for key and marks it as recently used.
+func (c *lruCache[K, V]) get(key K) (V, bool) {
+	e, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry[K, V]).value, true
+}
+
+// add sets the value for key, evicting the least recently used entry when
+// the cache is full.
+func (c *lruCache[K, V]) add(key K, value V) {
+	if e, ok := c.items[key]; ok {
+		e.Value.(*lruEntry[K, V]).value = value
+		c.order.MoveToFront(e)
+		return
+	}
+	if c.order.Len() >= c.size {
+		c.removeElement(c.order.Back())
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value})
+}
+
+// pruneOldest removes entries from the least recently used end for as long
+// as stale reports true, and returns how many it removed.
+func (c *lruCache[K, V]) pruneOldest(stale func(V) bool) int {
+	removed := 0
+	for e := c.order.Back(); e != nil && stale(e.Value.(*lruEntry[K, V]).value); e = c.order.Back() {
+		c.removeElement(e)
+		removed++
+	}
+	return removed
+}
+
+func (c *lruCache[K, V]) len() int {
+	return c.order.Len()
+}
+
+func (c *lruCache[K, V]) removeElement(e *list.Element) {
+	c.order.Remove(e)
+	delete(c.items, e.Value.(*lruEntry[K, V]).key)
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+type Notification struct {
+	Event     string    `json:"event"`
+	Scope     string    `json:"scope"`
+	ScopeID   string    `json:"scope_id"`
+	Reason    string    `json:"reason"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+type LogNotifier struct {
+	logger log.Logger
+}
+
+func NewLogNotifier(logger log.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, note Notification) error {
+	return n.logger.Log(
+		"event", note.Event,
+		"scope", note.Scope,
+		"scope_id", note.ScopeID,
+		"reason", note.Reason,
+		"msg", "notification",
+	)
+}
+
+// WebhookNotifier posts each notification as JSON to a fixed URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, note Notification) error {
+	body, err := json.Marshal(note)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
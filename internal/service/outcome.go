@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "project/migrations/sqlc"
+)
+
+// Reason codes recorded for every track request. They are internal: they are
+// logged and counted in outcome_counters but never rendered to the visitor.
+const (
+	ReasonRedirected      = "redirected"
+	ReasonMissingParams   = "missing_params"
+	ReasonUnknownLink     = "unknown_link"
+	ReasonLinkPaused      = "link_paused"
+	ReasonPublisherPaused = "publisher_paused"
+	ReasonCampaignPaused  = "campaign_paused"
+	ReasonNotStarted      = "not_started"
+	ReasonEnded           = "ended"
+	ReasonOutOfSchedule   = "out_of_schedule"
+	ReasonFraud           = "fraud"
+	ReasonMissingMacros   = "missing_macros"
+	ReasonFrequencyCapped = "frequency_capped"
+	ReasonCapped          = "capped"
+	ReasonBudgetExhausted = "budget_exhausted"
+	ReasonInvalidURL      = "invalid_url"
+	ReasonBadSignature    = "bad_signature"
+	ReasonChallenged      = "challenged"
+	ReasonChallengeFailed = "challenge_failed"
+	ReasonBotVisit        = "bot_visit"
+	ReasonPrefetch        = "prefetch"
+	ReasonEmailScanner    = "email_scanner"
+	ReasonBadTimezone     = "bad_timezone"
+)
+
+// Redirect modes, set on TrackOutput.RedirectMode for redirects. The transport
+// maps each to a status code and headers.
+const (
+	RedirectHTTP301      = string(db.RedirectModeHttp301)
+	RedirectHTTP302      = string(db.RedirectModeHttp302)
+	RedirectHTTP307      = string(db.RedirectModeHttp307)
+	RedirectMetaRefresh  = string(db.RedirectModeMetaRefresh)
+	RedirectJavaScript   = string(db.RedirectModeJavascript)
+	RedirectInterstitial = string(db.RedirectModeInterstitial)
+)
+
+// unavailable answers a request that will not reach the campaign's target.
+// It redirects to the campaign's fallback URL, then the global one, and
+// otherwise renders the unavailable page. A fallback URL that fails the URL
+// policy after substitution is skipped. Fallbacks always use a plain 302.
+// campaign is nil when the link could not be resolved; clickID is empty when
+// no click was recorded.
+func (s *clickService) unavailable(campaign *db.Campaign, req TrackInput, clickID, reason string) TrackOutput {
+	fallback := s.fallbackURL
+	if campaign != nil && campaign.FallbackUrl.Valid {
+		fallback = campaign.FallbackUrl.String
+	}
+
+	var data PageData
+	if campaign != nil {
+		data.Campaign = campaign.Name
+	}
+
+	if fallback != "" {
+		fallbackURL, _ := s.substituteMacros(fallback, req, clickID)
+		if err := s.urlPolicy.Validate(fallbackURL); err != nil {
+			fmt.Println("Error validating fallback url:", err)
+		} else {
+			out := s.redirectTo(fallbackURL, RedirectHTTP302, data)
+			out.Reason = reason
+			return out
+		}
+	}
+
+	return TrackOutput{
+		StatusCode: 200,
+		Body:       s.pages.renderUnavailable(data),
+		Reason:     reason,
+	}
+}
+
+// rejected answers a recorded click that will not reach the target. Capped
+// clicks only go to the fallback URL when cap_action is 'fallback'; otherwise
+// they always get the unavailable page.
+func (s *clickService) rejected(campaign db.Campaign, req TrackInput, clickID string, status db.ClickStatus, reason string) TrackOutput {
+	if status == db.ClickStatusCapped && campaign.CapAction != db.CapActionFallback {
+		return TrackOutput{
+			StatusCode: 200,
+			Body:       s.pages.renderUnavailable(PageData{Campaign: campaign.Name}),
+			Reason:     reason,
+		}
+	}
+	return s.unavailable(&campaign, req, clickID, reason)
+}
+
+func (s *clickService) redirectTo(redirectURL, mode string, data PageData) TrackOutput {
+	data.URL = redirectURL
+	return TrackOutput{
+		StatusCode:   302,
+		RedirectURL:  redirectURL,
+		RedirectMode: mode,
+		Body:         s.pages.renderRedirect(mode, data),
+	}
+}
+
+// OutcomeCounter tallies track request outcomes in memory and adds them to
+// outcome_counters in one batch per flush, so track requests never wait on
+// the hourly rows every request of a campaign would otherwise update.
+type OutcomeCounter struct {
+	queries  *db.Queries
+	interval time.Duration
+
+	mu     sync.Mutex
+	counts map[outcomeKey]int64
+}
+
+type outcomeKey struct {
+	hour       time.Time
+	campaignID uuid.UUID
+	reason     string
+}
+
+var DefaultOutcomeFlushInterval = 10 * time.Second
+
+// NewOutcomeCounter returns a counter that flushes every interval once Run is
+// started.
+func NewOutcomeCounter(q *db.Queries, interval time.Duration) *OutcomeCounter {
+	if interval <= 0 {
+		interval = DefaultOutcomeFlushInterval
+	}
+	return &OutcomeCounter{
+		queries:  q,
+		interval: interval,
+		counts:   make(map[outcomeKey]int64),
+	}
+}
+
+// Add counts one request with reason against the current hour.
+func (c *OutcomeCounter) Add(campaignID uuid.UUID, reason string) {
+	c.add(outcomeKey{
+		hour:       time.Now().UTC().Truncate(time.Hour),
+		campaignID: campaignID,
+		reason:     reason,
+	}, 1)
+}
+
+func (c *OutcomeCounter) add(key outcomeKey, hits int64) {
+	c.mu.Lock()
+	c.counts[key] += hits
+	c.mu.Unlock()
+}
+
+func (c *OutcomeCounter) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Flush(ctx); err != nil {
+				fmt.Println("Error counting outcomes:", err)
+			}
+		}
+	}
+}
+
+// Flush writes the counts gathered since the last flush. Counts that fail to
+// write are kept for the next one.
+func (c *OutcomeCounter) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	counts := c.counts
+	c.counts = make(map[outcomeKey]int64, len(counts))
+	c.mu.Unlock()
+
+	var firstErr error
+	for key, hits := range counts {
+		if firstErr == nil {
+			firstErr = c.queries.IncrementOutcomeCounter(ctx, db.IncrementOutcomeCounterParams{
+				Hour:       pgtype.Timestamp{Time: key.hour, Valid: true},
+				CampaignID: key.campaignID,
+				Reason:     key.reason,
+				Hits:       hits,
+			})
+			if firstErr == nil {
+				continue
+			}
+		}
+		c.add(key, hits)
+	}
+	return firstErr
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+//go:embed templates/*.html
+var defaultTemplates embed.FS
+
+// partialsName is the file of templates shared between pages. It is parsed
+// with every page and can be overridden from TEMPLATE_DIR like one.
+const partialsName = "partials.html"
+
+// pageNames are the templates that can be overridden from TEMPLATE_DIR.
+var pageNames = []string{
+	"unavailable.html",
+	"redirect.html",
+	"javascript.html",
+	"interstitial.html",
+	"challenge.html",
+	"preview.html",
+}
+
+// PageData is passed to every page template. It deliberately carries no
+// outcome reason so fraud signals are never shown to the visitor.
+type PageData struct {
+	Campaign string
+	// URL is the redirect destination on redirect pages.
+	URL string
+	// Token and Difficulty are set on the challenge page.
+	Token      string
+	Difficulty int
+}
+
+// Pages holds the HTML templates served with redirects and in place of them.
+type Pages struct {
+	templates map[string]*template.Template
+}
+
+// LoadPages parses the page templates. A file in dir with the same name as a
+// built-in template (see pageNames) replaces it; dir may be empty to use the
+// built-in templates only.
+func LoadPages(dir string) (*Pages, error) {
+	p := &Pages{templates: make(map[string]*template.Template, len(pageNames))}
+	for _, name := range pageNames {
+		t, err := loadTemplate(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		p.templates[name] = t
+	}
+	return p, nil
+}
+
+func loadTemplate(dir, name string) (*template.Template, error) {
+	src, err := readTemplate(dir, partialsName)
+	if err != nil {
+		return nil, err
+	}
+	t, err := template.New(partialsName).Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err = readTemplate(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return t.New(name).Parse(src)
+}
+
+// readTemplate returns the source of a template from dir, or the built-in one
+// when dir is empty or has no file of that name.
+func readTemplate(dir, name string) (string, error) {
+	if dir != "" {
+		src, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(src), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	src, err := defaultTemplates.ReadFile("templates/" + name)
+	return string(src), err
+}
+
+// DefaultPages returns the built-in templates.
+func DefaultPages() *Pages {
+	p, err := LoadPages("")
+	if err != nil {
+		panic(fmt.Sprintf("parsing built-in page templates: %v", err))
+	}
+	return p
+}
+
+func (p *Pages) render(name string, data PageData) (string, error) {
+	var buf bytes.Buffer
+	if err := p.templates[name].Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (p *Pages) renderUnavailable(data PageData) string {
+	body, err := p.render("unavailable.html", data)
+	if err != nil {
+		fmt.Println("Error rendering unavailable page:", err)
+		return "<html><body>campaign not available</body></html>"
+	}
+	return body
+}
+
+// renderPreview renders the Open Graph page served to link-preview crawlers.
+func (p *Pages) renderPreview(data PageData) string {
+	body, err := p.render("preview.html", data)
+	if err != nil {
+		fmt.Println("Error rendering preview page:", err)
+		return "<html><body></body></html>"
+	}
+	return body
+}
+
+// renderRedirect renders the page for a redirect mode. The HTTP modes use the
+// meta-refresh page as the response body for clients that ignore Location.
+func (p *Pages) renderRedirect(mode string, data PageData) string {
+	name := "redirect.html"
+	switch mode {
+	case RedirectJavaScript:
+		name = "javascript.html"
+	case RedirectInterstitial:
+		name = "interstitial.html"
+	}
+
+	body, err := p.render(name, data)
+	if err != nil {
+		fmt.Println("Error rendering redirect page:", err)
+		return "<html><body>Redirecting...</body></html>"
+	}
+	return body
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+
+	db "project/migrations/sqlc"
+)
+
+// PlatformCheck blocks clicks whose advertising ID, user agent and campaign
+// disagree about the platform: a GAID only exists on Android and an IDFA
+// only on iOS, so an IDFA with an Android user agent or a GAID with an
+// iPhone one was put together by hand.
+type PlatformCheck struct{}
+
+func NewPlatformCheck() *PlatformCheck {
+	return &PlatformCheck{}
+}
+
+func (c *PlatformCheck) Name() string {
+	return "platform"
+}
+
+func (c *PlatformCheck) Check(ctx context.Context, input TrackInput, clickID string) FraudCheckResult {
+	if input.GAID != "" && input.IDFA != "" {
+		return FraudCheckResult{
+			Block:  true,
+			Reason: "platform: both gaid and idfa provided",
+		}
+	}
+
+	idName, idPlatform := "", ""
+	switch {
+	case input.GAID != "":
+		idName, idPlatform = "gaid", "Android"
+	case input.IDFA != "":
+		idName, idPlatform = "idfa", "iOS"
+	}
+
+	uaPlatform := platformFromUA(input.UserAgent)
+	if idPlatform != "" && !uaMatchesPlatform(uaPlatform, idPlatform) {
+		return FraudCheckResult{
+			Block:  true,
+			Reason: "platform: " + idName + " sent with " + article(uaPlatform) + " " + uaPlatform + " user agent",
+		}
+	}
+
+	if campaign, ok := campaignFromContext(ctx); ok && campaign.TargetPlatform != db.TargetPlatformAny {
+		target := "Android"
+		if campaign.TargetPlatform == db.TargetPlatformIos {
+			target = "iOS"
+		}
+		if idPlatform != "" && idPlatform != target {
+			return FraudCheckResult{
+				Block:  true,
+				Reason: "platform: " + idName + " sent to " + article(target) + " " + target + " campaign",
+			}
+		}
+		if !uaMatchesPlatform(uaPlatform, target) {
+			return FraudCheckResult{
+				Block:  true,
+				Reason: "platform: " + uaPlatform + " user agent on " + article(target) + " " + target + " campaign",
+			}
+		}
+	}
+
+	return FraudCheckResult{
+		Block:  false,
+		Reason: "platform: device ids and user agent are consistent",
+	}
+}
+
+// uaMatchesPlatform reports whether a uaPlatform user agent can come from a
+// platform device. iPads request desktop sites by default and send a
+// Macintosh user agent, Android Chrome's desktop site mode sends a Linux one,
+// and Chromebooks run Android apps. An unrecognised user agent matches.
+func uaMatchesPlatform(uaPlatform, platform string) bool {
+	switch {
+	case uaPlatform == "" || uaPlatform == platform:
+		return true
+	case platform == "iOS":
+		return uaPlatform == "macOS"
+	case platform == "Android":
+		return uaPlatform == "Linux" || uaPlatform == "Chrome OS"
+	}
+	return false
+}
+
+func article(platform string) string {
+	if platform == "Android" || platform == "iOS" {
+		return "an"
+	}
+	return "a"
+}
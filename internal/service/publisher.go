@@ -0,0 +1,398 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "project/migrations/sqlc"
+)
+
+type PublisherService interface {
+	CreatePublisher(ctx context.Context, req PublisherInput) (Publisher, error)
+	ListPublishers(ctx context.Context) ([]Publisher, error)
+	CreateTrackingLink(ctx context.Context, req TrackingLinkInput) (TrackingLink, error)
+	ListTrackingLinks(ctx context.Context, campaignID string) ([]TrackingLink, error)
+	SetPublisherStatus(ctx context.Context, req StatusInput) (Publisher, error)
+	SetTrackingLinkStatus(ctx context.Context, req StatusInput) (TrackingLink, error)
+	SetTrackingLinkSlug(ctx context.Context, req SlugInput) (TrackingLink, error)
+	ListQualityScores(ctx context.Context, scope string) ([]QualityScore, error)
+}
+
+type PublisherInput struct {
+	Name string
+	// SiteDomains are the sites the publisher runs; clicks referred from
+	// other domains fail the referrer check.
+	SiteDomains []string
+}
+
+type Publisher struct {
+	PublisherID     string    `json:"publisher_id"`
+	Name            string    `json:"name"`
+	Status          string    `json:"status"`
+	QualityOverride bool      `json:"quality_override"`
+	PausedReason    string    `json:"paused_reason,omitempty"`
+	SiteDomains     []string  `json:"site_domains"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type TrackingLinkInput struct {
+	CampaignID   string
+	PublisherID  string
+	DailyCap     *int64
+	TotalCap     *int64
+	PayoutMicros int64
+	CpcMicros    *int64
+	// Slug is a vanity slug; a random base62 slug is generated when empty.
+	Slug string
+}
+
+type TrackingLink struct {
+	LinkID          string    `json:"link_id"`
+	CampaignID      string    `json:"campaign_id"`
+	PublisherID     string    `json:"publisher_id"`
+	Status          string    `json:"status"`
+	DailyCap        *int64    `json:"daily_cap"`
+	TotalCap        *int64    `json:"total_cap"`
+	PayoutMicros    int64     `json:"payout_micros"`
+	CpcMicros       *int64    `json:"cpc_micros"`
+	Slug            string    `json:"slug,omitempty"`
+	QualityOverride bool      `json:"quality_override"`
+	PausedReason    string    `json:"paused_reason,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// SlugInput sets a link's slug; an empty Slug generates a random one.
+type SlugInput struct {
+	LinkID string
+	Slug   string
+}
+
+// StatusInput is a manual status change. Setting QualityOverride keeps the
+// quality job from pausing the entity again after it is re-activated.
+type StatusInput struct {
+	ID              string
+	Status          string
+	QualityOverride bool
+}
+
+type QualityScore struct {
+	Scope             string    `json:"scope"`
+	ScopeID           string    `json:"scope_id"`
+	ComputedAt        time.Time `json:"computed_at"`
+	WindowStart       time.Time `json:"window_start"`
+	TotalClicks       int64     `json:"total_clicks"`
+	FraudRate         float64   `json:"fraud_rate"`
+	FlaggedRate       float64   `json:"flagged_rate"`
+	DuplicateRate     float64   `json:"duplicate_rate"`
+	TimingAnomalyRate float64   `json:"timing_anomaly_rate"`
+	Score             float64   `json:"score"`
+}
+
+type publisherService struct {
+	queries *db.Queries
+}
+
+func NewPublisherService(q *db.Queries) PublisherService {
+	return &publisherService{queries: q}
+}
+
+func (s *publisherService) CreatePublisher(ctx context.Context, req PublisherInput) (Publisher, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return Publisher{}, fmt.Errorf("%w: name is required", ErrInvalidArgument)
+	}
+
+	siteDomains, err := normalizeDomains("site_domains", req.SiteDomains)
+	if err != nil {
+		return Publisher{}, err
+	}
+
+	p, err := s.queries.CreatePublisher(ctx, db.CreatePublisherParams{
+		PublisherID: uuid.New(),
+		Name:        name,
+		SiteDomains: siteDomains,
+	})
+	if err != nil {
+		return Publisher{}, err
+	}
+
+	return toPublisher(p), nil
+}
+
+func (s *publisherService) ListPublishers(ctx context.Context) ([]Publisher, error) {
+	rows, err := s.queries.ListPublishers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	publishers := make([]Publisher, 0, len(rows))
+	for _, p := range rows {
+		publishers = append(publishers, toPublisher(p))
+	}
+
+	return publishers, nil
+}
+
+func (s *publisherService) CreateTrackingLink(ctx context.Context, req TrackingLinkInput) (TrackingLink, error) {
+	campaignID, err := uuid.Parse(req.CampaignID)
+	if err != nil {
+		return TrackingLink{}, fmt.Errorf("%w: campaign_id must be a uuid", ErrInvalidArgument)
+	}
+
+	publisherID, err := uuid.Parse(req.PublisherID)
+	if err != nil {
+		return TrackingLink{}, fmt.Errorf("%w: publisher_id must be a uuid", ErrInvalidArgument)
+	}
+
+	if (req.DailyCap != nil && *req.DailyCap <= 0) || (req.TotalCap != nil && *req.TotalCap <= 0) {
+		return TrackingLink{}, fmt.Errorf("%w: caps must be positive", ErrInvalidArgument)
+	}
+
+	if req.PayoutMicros < 0 || (req.CpcMicros != nil && *req.CpcMicros < 0) {
+		return TrackingLink{}, fmt.Errorf("%w: payout_micros and cpc_micros must not be negative", ErrInvalidArgument)
+	}
+
+	if req.Slug != "" {
+		if err := validateSlug(req.Slug); err != nil {
+			return TrackingLink{}, err
+		}
+	}
+
+	params := db.CreateTrackingLinkParams{
+		LinkID:       uuid.New(),
+		CampaignID:   campaignID,
+		PublisherID:  publisherID,
+		DailyCap:     toInt8(req.DailyCap),
+		TotalCap:     toInt8(req.TotalCap),
+		PayoutMicros: req.PayoutMicros,
+		CpcMicros:    toInt8(req.CpcMicros),
+	}
+	link, err := withSlug(req.Slug, func(slug string) (db.TrackingLink, error) {
+		params.Slug = pgtype.Text{String: slug, Valid: true}
+		return s.queries.CreateTrackingLink(ctx, params)
+	})
+	if isForeignKeyViolation(err) {
+		return TrackingLink{}, fmt.Errorf("%w: campaign or publisher does not exist", ErrInvalidArgument)
+	}
+	if err != nil {
+		return TrackingLink{}, err
+	}
+
+	return toTrackingLink(link), nil
+}
+
+func (s *publisherService) ListTrackingLinks(ctx context.Context, campaignID string) ([]TrackingLink, error) {
+	id, err := uuid.Parse(campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: campaign_id must be a uuid", ErrInvalidArgument)
+	}
+
+	if _, err := s.queries.GetCampaign(ctx, id); errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: campaign %s", ErrNotFound, id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.queries.ListTrackingLinksByCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]TrackingLink, 0, len(rows))
+	for _, l := range rows {
+		links = append(links, toTrackingLink(l))
+	}
+
+	return links, nil
+}
+
+func (s *publisherService) SetPublisherStatus(ctx context.Context, req StatusInput) (Publisher, error) {
+	id, err := uuid.Parse(req.ID)
+	if err != nil {
+		return Publisher{}, fmt.Errorf("%w: publisher_id must be a uuid", ErrInvalidArgument)
+	}
+
+	status := db.PublisherStatus(req.Status)
+	if status != db.PublisherStatusActive && status != db.PublisherStatusPaused {
+		return Publisher{}, fmt.Errorf("%w: status must be active or paused", ErrInvalidArgument)
+	}
+
+	p, err := s.queries.SetPublisherStatus(ctx, db.SetPublisherStatusParams{
+		PublisherID:     id,
+		Status:          status,
+		QualityOverride: req.QualityOverride,
+		PausedReason:    manualPausedReason(req.Status),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Publisher{}, fmt.Errorf("%w: publisher %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return Publisher{}, err
+	}
+
+	return toPublisher(p), nil
+}
+
+func (s *publisherService) SetTrackingLinkStatus(ctx context.Context, req StatusInput) (TrackingLink, error) {
+	id, err := uuid.Parse(req.ID)
+	if err != nil {
+		return TrackingLink{}, fmt.Errorf("%w: link_id must be a uuid", ErrInvalidArgument)
+	}
+
+	status := db.LinkStatus(req.Status)
+	if status != db.LinkStatusActive && status != db.LinkStatusPaused {
+		return TrackingLink{}, fmt.Errorf("%w: status must be active or paused", ErrInvalidArgument)
+	}
+
+	l, err := s.queries.SetTrackingLinkStatus(ctx, db.SetTrackingLinkStatusParams{
+		LinkID:          id,
+		Status:          status,
+		QualityOverride: req.QualityOverride,
+		PausedReason:    manualPausedReason(req.Status),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return TrackingLink{}, fmt.Errorf("%w: link %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return TrackingLink{}, err
+	}
+
+	return toTrackingLink(l), nil
+}
+
+func (s *publisherService) SetTrackingLinkSlug(ctx context.Context, req SlugInput) (TrackingLink, error) {
+	id, err := uuid.Parse(req.LinkID)
+	if err != nil {
+		return TrackingLink{}, fmt.Errorf("%w: link_id must be a uuid", ErrInvalidArgument)
+	}
+
+	if req.Slug != "" {
+		if err := validateSlug(req.Slug); err != nil {
+			return TrackingLink{}, err
+		}
+	}
+
+	l, err := withSlug(req.Slug, func(slug string) (db.TrackingLink, error) {
+		return s.queries.SetTrackingLinkSlug(ctx, db.SetTrackingLinkSlugParams{
+			LinkID: id,
+			Slug:   pgtype.Text{String: slug, Valid: true},
+		})
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return TrackingLink{}, fmt.Errorf("%w: link %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return TrackingLink{}, err
+	}
+
+	return toTrackingLink(l), nil
+}
+
+// withSlug runs write with the requested slug, or with random slugs until
+// one is not taken. A requested slug that is taken is an invalid argument.
+func withSlug(slug string, write func(slug string) (db.TrackingLink, error)) (db.TrackingLink, error) {
+	if slug != "" {
+		l, err := write(slug)
+		if isUniqueViolation(err) {
+			return l, fmt.Errorf("%w: slug %q is already in use", ErrInvalidArgument, slug)
+		}
+		return l, err
+	}
+
+	var l db.TrackingLink
+	var err error
+	for range slugAttempts {
+		l, err = write(randomSlug())
+		if !isUniqueViolation(err) {
+			return l, err
+		}
+	}
+	return l, err
+}
+
+func (s *publisherService) ListQualityScores(ctx context.Context, scope string) ([]QualityScore, error) {
+	qs := db.QualityScope(scope)
+	if qs != db.QualityScopePublisher && qs != db.QualityScopeLink {
+		return nil, fmt.Errorf("%w: scope must be publisher or link", ErrInvalidArgument)
+	}
+
+	rows, err := s.queries.ListLatestQualityScores(ctx, qs)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]QualityScore, 0, len(rows))
+	for _, row := range rows {
+		scores = append(scores, QualityScore{
+			Scope:             string(row.Scope),
+			ScopeID:           row.ScopeID.String(),
+			ComputedAt:        row.ComputedAt.Time,
+			WindowStart:       row.WindowStart.Time,
+			TotalClicks:       row.TotalClicks,
+			FraudRate:         row.FraudRate,
+			FlaggedRate:       row.FlaggedRate,
+			DuplicateRate:     row.DuplicateRate,
+			TimingAnomalyRate: row.TimingAnomalyRate,
+			Score:             row.Score,
+		})
+	}
+
+	return scores, nil
+}
+
+func manualPausedReason(status string) pgtype.Text {
+	if status == "paused" {
+		return pgtype.Text{String: "manual", Valid: true}
+	}
+	return pgtype.Text{}
+}
+
+func toPublisher(p db.Publisher) Publisher {
+	return Publisher{
+		PublisherID:     p.PublisherID.String(),
+		Name:            p.Name,
+		Status:          string(p.Status),
+		QualityOverride: p.QualityOverride,
+		PausedReason:    p.PausedReason.String,
+		SiteDomains:     p.SiteDomains,
+		CreatedAt:       p.CreatedAt.Time,
+	}
+}
+
+func toTrackingLink(l db.TrackingLink) TrackingLink {
+	return TrackingLink{
+		LinkID:          l.LinkID.String(),
+		CampaignID:      l.CampaignID.String(),
+		PublisherID:     l.PublisherID.String(),
+		Status:          string(l.Status),
+		DailyCap:        fromInt8(l.DailyCap),
+		TotalCap:        fromInt8(l.TotalCap),
+		PayoutMicros:    l.PayoutMicros,
+		CpcMicros:       fromInt8(l.CpcMicros),
+		Slug:            l.Slug.String,
+		QualityOverride: l.QualityOverride,
+		PausedReason:    l.PausedReason.String,
+		CreatedAt:       l.CreatedAt.Time,
+	}
+}
+
+func toInt8(v *int64) pgtype.Int8 {
+	if v == nil {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: *v, Valid: true}
+}
+
+func fromInt8(v pgtype.Int8) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
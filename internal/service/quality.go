@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "project/migrations/sqlc"
+)
+
+type QualityConfig struct {
+	Interval         time.Duration
+	Window           time.Duration
+	MinClicks        int64
+	MaxFraudRate     float64
+	MaxDuplicateRate float64
+	MinScore         float64
+}
+
+var DefaultQualityConfig = QualityConfig{
+	Interval:         15 * time.Minute,
+	Window:           24 * time.Hour,
+	MinClicks:        200,
+	MaxFraudRate:     0.5,
+	MaxDuplicateRate: 0.6,
+	MinScore:         0.4,
+}
+
+// QualityJob periodically scores every publisher and tracking link that
+// received clicks in the configured window and pauses the ones that cross
+// the thresholds. Entities with quality_override set are scored but never
+// paused automatically.
+type QualityJob struct {
+	queries  *db.Queries
+	cfg      QualityConfig
+	notifier Notifier
+}
+
+func NewQualityJob(q *db.Queries, cfg QualityConfig, notifier Notifier) *QualityJob {
+	return &QualityJob{
+		queries:  q,
+		cfg:      cfg,
+		notifier: notifier,
+	}
+}
+
+func (j *QualityJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.RunOnce(ctx); err != nil {
+				fmt.Println("Error scoring publisher quality:", err)
+			}
+		}
+	}
+}
+
+func (j *QualityJob) RunOnce(ctx context.Context) error {
+	// The window is computed by the database, against the same clock that
+	// stamps clicks.
+	window := j.cfg.Window.Seconds()
+
+	rows, err := j.queries.LinkQualityStats(ctx, window)
+	if err != nil {
+		return err
+	}
+
+	publishers := make(map[uuid.UUID]qualityStats)
+	for _, row := range rows {
+		stats := qualityStats{
+			total:     row.TotalClicks,
+			fraud:     row.FraudClicks,
+			flagged:   row.FlaggedClicks,
+			duplicate: row.DuplicateClicks,
+			fast:      row.FastClicks,
+		}
+		publishers[row.PublisherID] = publishers[row.PublisherID].add(stats)
+
+		if err := j.record(ctx, db.QualityScopeLink, row.LinkID, window, stats); err != nil {
+			return err
+		}
+	}
+
+	for publisherID, stats := range publishers {
+		if err := j.record(ctx, db.QualityScopePublisher, publisherID, window, stats); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (j *QualityJob) record(ctx context.Context, scope db.QualityScope, id uuid.UUID, window float64, stats qualityStats) error {
+	fraudRate, flaggedRate, duplicateRate, timingRate := stats.rates()
+	score := stats.score()
+
+	err := j.queries.InsertQualityScore(ctx, db.InsertQualityScoreParams{
+		Scope:             scope,
+		ScopeID:           id,
+		WindowSeconds:     window,
+		TotalClicks:       stats.total,
+		FraudRate:         fraudRate,
+		FlaggedRate:       flaggedRate,
+		DuplicateRate:     duplicateRate,
+		TimingAnomalyRate: timingRate,
+		Score:             score,
+	})
+	if err != nil {
+		return err
+	}
+
+	reason := j.violation(stats)
+	if reason == "" {
+		return nil
+	}
+
+	pausedReason := pgtype.Text{String: "quality: " + reason, Valid: true}
+	var paused int64
+	switch scope {
+	case db.QualityScopeLink:
+		paused, err = j.queries.AutoPauseTrackingLink(ctx, db.AutoPauseTrackingLinkParams{
+			LinkID:       id,
+			PausedReason: pausedReason,
+		})
+	case db.QualityScopePublisher:
+		paused, err = j.queries.AutoPausePublisher(ctx, db.AutoPausePublisherParams{
+			PublisherID:  id,
+			PausedReason: pausedReason,
+		})
+	}
+	if err != nil || paused == 0 {
+		return err
+	}
+
+	err = j.notifier.Notify(ctx, Notification{
+		Event:     "auto_paused",
+		Scope:     string(scope),
+		ScopeID:   id.String(),
+		Reason:    reason,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		fmt.Println("Error sending quality notification:", err)
+	}
+
+	return nil
+}
+
+// violation returns a description of the first threshold the stats exceed,
+// or "" when they are within limits or the sample is too small to judge.
+func (j *QualityJob) violation(stats qualityStats) string {
+	if stats.total < j.cfg.MinClicks {
+		return ""
+	}
+
+	fraudRate, _, duplicateRate, _ := stats.rates()
+	switch {
+	case fraudRate > j.cfg.MaxFraudRate:
+		return fmt.Sprintf("fraud rate %.2f exceeds %.2f", fraudRate, j.cfg.MaxFraudRate)
+	case duplicateRate > j.cfg.MaxDuplicateRate:
+		return fmt.Sprintf("duplicate rate %.2f exceeds %.2f", duplicateRate, j.cfg.MaxDuplicateRate)
+	case stats.score() < j.cfg.MinScore:
+		return fmt.Sprintf("quality score %.2f below %.2f", stats.score(), j.cfg.MinScore)
+	}
+	return ""
+}
+
+// qualityStats are raw click counts for one publisher or link. There are no
+// install postbacks to measure click-to-install time against, so fast counts
+// repeat clicks by the same user on the same link less than a second apart,
+// which is the click-side symptom of the same injection tooling.
+type qualityStats struct {
+	total     int64
+	fraud     int64
+	flagged   int64
+	duplicate int64
+	fast      int64
+}
+
+func (s qualityStats) add(o qualityStats) qualityStats {
+	return qualityStats{
+		total:     s.total + o.total,
+		fraud:     s.fraud + o.fraud,
+		flagged:   s.flagged + o.flagged,
+		duplicate: s.duplicate + o.duplicate,
+		fast:      s.fast + o.fast,
+	}
+}
+
+func (s qualityStats) rates() (fraud, flagged, duplicate, timing float64) {
+	if s.total == 0 {
+		return 0, 0, 0, 0
+	}
+	total := float64(s.total)
+	return float64(s.fraud) / total,
+		float64(s.flagged) / total,
+		float64(s.duplicate) / total,
+		float64(s.fast) / total
+}
+
+// score is 1 for clean traffic and approaches 0 as every signal saturates.
+func (s qualityStats) score() float64 {
+	fraud, flagged, duplicate, timing := s.rates()
+	return max(0, 1-(0.5*fraud+0.2*flagged+0.15*duplicate+0.15*timing))
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	db "project/migrations/sqlc"
+)
+
+// referrerDomain returns the lowercased host of a referrer URL without a
+// trailing dot, a leading "www." or the brackets around an IPv6 address, or
+// an empty string when the referrer has no scheme or host. It must agree with
+// the referrer_domain report dimension in report.sql.
+func referrerDomain(referrer string) string {
+	u, err := url.Parse(strings.TrimSpace(referrer))
+	if err != nil || u.Scheme == "" {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSuffix(strings.ToLower(u.Hostname()), "."), "www.")
+}
+
+// normalizeDomains validates a list of referrer domains, accepting bare
+// domains, "*." wildcards and URLs, and returns them as plain lowercase
+// domains.
+func normalizeDomains(field string, domains []string) ([]string, error) {
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		d := strings.ToLower(strings.TrimSpace(domain))
+		if strings.Contains(d, "://") {
+			d = referrerDomain(d)
+		}
+		d = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSuffix(d, "."), "*."), "www.")
+		if d == "" || strings.ContainsAny(d, "/:?#@ ") {
+			return nil, fmt.Errorf("%w: invalid domain %q in %s", ErrInvalidArgument, domain, field)
+		}
+		if !slices.Contains(normalized, d) {
+			normalized = append(normalized, d)
+		}
+	}
+	return normalized, nil
+}
+
+// matchDomain reports whether domain is one of domains or a subdomain of one.
+func matchDomain(domain string, domains []string) bool {
+	for _, d := range domains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReferrerCheck blocks clicks whose referrer is on a domain the campaign
+// blocks, or on neither the publisher's declared sites nor the campaign's
+// allowed domains when either list is set. Clicks without a referrer, which
+// in-app clicks often are, fail only when the campaign's empty_referrer
+// policy is flag. Without any of these policies the referrer is not looked
+// at.
+type ReferrerCheck struct{}
+
+func NewReferrerCheck() *ReferrerCheck {
+	return &ReferrerCheck{}
+}
+
+func (c *ReferrerCheck) Name() string {
+	return "referrer"
+}
+
+func (c *ReferrerCheck) Check(ctx context.Context, input TrackInput, clickID string) FraudCheckResult {
+	campaign, ok := campaignFromContext(ctx)
+	if !ok {
+		return FraudCheckResult{
+			Block:  false,
+			Reason: "referrer: no campaign",
+		}
+	}
+
+	publisher, _ := publisherFromContext(ctx)
+	domainPolicy := len(campaign.BlockedReferrerDomains) > 0 ||
+		len(campaign.AllowedReferrerDomains) > 0 ||
+		len(publisher.SiteDomains) > 0
+	if !domainPolicy && campaign.EmptyReferrer != db.EmptyReferrerPolicyFlag {
+		return FraudCheckResult{
+			Block:  false,
+			Reason: "referrer: no referrer policy",
+		}
+	}
+
+	if strings.TrimSpace(input.Referrer) == "" {
+		if campaign.EmptyReferrer == db.EmptyReferrerPolicyFlag {
+			return FraudCheckResult{
+				Block:  true,
+				Reason: "referrer: no referrer sent",
+			}
+		}
+		return FraudCheckResult{
+			Block:  false,
+			Reason: "referrer: referrer not provided",
+		}
+	}
+
+	if !domainPolicy {
+		return FraudCheckResult{
+			Block:  false,
+			Reason: "referrer: no referrer domain policy",
+		}
+	}
+
+	domain := referrerDomain(input.Referrer)
+	if domain == "" {
+		return FraudCheckResult{
+			Block:  true,
+			Reason: "referrer: referrer is not a URL",
+		}
+	}
+
+	if matchDomain(domain, campaign.BlockedReferrerDomains) {
+		return FraudCheckResult{
+			Block:  true,
+			Reason: "referrer: " + domain + " is blocked by the campaign",
+		}
+	}
+
+	if len(publisher.SiteDomains) > 0 || len(campaign.AllowedReferrerDomains) > 0 {
+		if !matchDomain(domain, publisher.SiteDomains) && !matchDomain(domain, campaign.AllowedReferrerDomains) {
+			return FraudCheckResult{
+				Block:  true,
+				Reason: "referrer: " + domain + " is not a site of the publisher or an allowed domain",
+			}
+		}
+	}
+
+	return FraudCheckResult{
+		Block:  false,
+		Reason: "referrer: referrer domain allowed",
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "project/migrations/sqlc"
+)
+
+type ReportService interface {
+	ClickReport(ctx context.Context, req ReportInput) ([]ReportRow, error)
+	OutcomeReport(ctx context.Context, req OutcomeReportInput) ([]OutcomeRow, error)
+}
+
+type ReportInput struct {
+	CampaignID string
+	Dimension  string
+	From       time.Time
+	To         time.Time
+}
+
+type ReportRow struct {
+	Dimension    string `json:"dimension"`
+	Value        string `json:"value"`
+	Status       string `json:"status"`
+	Clicks       int64  `json:"clicks"`
+	UniqueClicks int64  `json:"unique_clicks"`
+}
+
+type OutcomeReportInput struct {
+	// CampaignID is optional; when empty all campaigns are reported,
+	// including unresolved links under the nil uuid.
+	CampaignID string
+	From       time.Time
+	To         time.Time
+}
+
+type OutcomeRow struct {
+	CampaignID uuid.UUID `json:"campaign_id"`
+	Reason     string    `json:"reason"`
+	Hits       int64     `json:"hits"`
+}
+
+// builtinDimensions are click columns that can be reported on in addition to
+// the configured passthrough params. referrer_domain is the host of the
+// referrer, as matched by ReferrerCheck.
+var builtinDimensions = []string{"publisher_id", "link_id", "referrer_domain"}
+
+type reportService struct {
+	queries    *db.Queries
+	dimensions []string
+}
+
+func NewReportService(q *db.Queries, passthroughParams []string) ReportService {
+	return &reportService{
+		queries:    q,
+		dimensions: append(slices.Clone(builtinDimensions), passthroughParams...),
+	}
+}
+
+func (s *reportService) ClickReport(ctx context.Context, req ReportInput) ([]ReportRow, error) {
+	campaignID, err := uuid.Parse(req.CampaignID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: campaign_id must be a uuid", ErrInvalidArgument)
+	}
+
+	if !slices.Contains(s.dimensions, req.Dimension) {
+		return nil, fmt.Errorf("%w: unknown dimension %q", ErrInvalidArgument, req.Dimension)
+	}
+
+	if !req.From.Before(req.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidArgument)
+	}
+
+	rows, err := s.queries.ClickReport(ctx, db.ClickReportParams{
+		Dimension:  req.Dimension,
+		CampaignID: campaignID,
+		FromTime:   pgtype.Timestamp{Time: req.From.UTC(), Valid: true},
+		ToTime:     pgtype.Timestamp{Time: req.To.UTC(), Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]ReportRow, 0, len(rows))
+	for _, row := range rows {
+		report = append(report, ReportRow{
+			Dimension:    req.Dimension,
+			Value:        row.DimensionValue,
+			Status:       string(row.Status),
+			Clicks:       row.ClickCount,
+			UniqueClicks: row.UniqueCount,
+		})
+	}
+
+	return report, nil
+}
+
+// OutcomeReport counts track requests by reason code. Outcomes are bucketed
+// by hour, so from and to are effectively rounded down to the hour.
+func (s *reportService) OutcomeReport(ctx context.Context, req OutcomeReportInput) ([]OutcomeRow, error) {
+	var campaignID pgtype.UUID
+	if req.CampaignID != "" {
+		id, err := uuid.Parse(req.CampaignID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: campaign_id must be a uuid", ErrInvalidArgument)
+		}
+		campaignID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	if !req.From.Before(req.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidArgument)
+	}
+
+	rows, err := s.queries.OutcomeReport(ctx, db.OutcomeReportParams{
+		FromTime:   pgtype.Timestamp{Time: req.From.UTC(), Valid: true},
+		ToTime:     pgtype.Timestamp{Time: req.To.UTC(), Valid: true},
+		CampaignID: campaignID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]OutcomeRow, 0, len(rows))
+	for _, row := range rows {
+		report = append(report, OutcomeRow(row))
+	}
+
+	return report, nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// isPrefetch reports whether the request is a speculative prefetch or
+// prerender rather than a navigation. Browsers discard such a response when
+// it is not a 2xx and fetch the link again if the user actually follows it.
+func isPrefetch(input TrackInput) bool {
+	for _, name := range []string{"Sec-Purpose", "Purpose", "X-Purpose", "X-Moz"} {
+		v := strings.ToLower(input.Headers.Get(name))
+		if strings.Contains(v, "prefetch") || strings.Contains(v, "prerender") || strings.Contains(v, "preview") {
+			return true
+		}
+	}
+	return false
+}
+
+type ScannerConfig struct {
+	// Window is how close together hits from one address must be to look
+	// automated.
+	Window time.Duration
+	// FanOutLinks is the number of distinct links opened from one address
+	// within Window that marks it as a gateway opening every link in a
+	// message.
+	FanOutLinks int
+}
+
+var DefaultScannerConfig = ScannerConfig{
+	Window:      time.Second,
+	FanOutLinks: 3,
+}
+
+// scannerDetector remembers recent hits per address to spot email security
+// gateways, which open links moments after delivery, often more than once
+// and all links of a message at a time.
+type scannerDetector struct {
+	cfg ScannerConfig
+
+	mu        sync.Mutex
+	hits      map[string]map[uuid.UUID]time.Time
+	lastSweep time.Time
+}
+
+func newScannerDetector(cfg ScannerConfig) *scannerDetector {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultScannerConfig.Window
+	}
+	if cfg.FanOutLinks <= 0 {
+		cfg.FanOutLinks = DefaultScannerConfig.FanOutLinks
+	}
+	return &scannerDetector{cfg: cfg, hits: make(map[string]map[uuid.UUID]time.Time)}
+}
+
+// observe records a hit on linkID from ip and returns the heuristic it
+// trips, or an empty string.
+func (d *scannerDetector) observe(ip string, linkID uuid.UUID, now time.Time) string {
+	if ip == "" {
+		return ""
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.lastSweep) > 10*d.cfg.Window {
+		d.sweep(now)
+	}
+
+	links := d.hits[ip]
+	if links == nil {
+		links = make(map[uuid.UUID]time.Time)
+		d.hits[ip] = links
+	}
+
+	last, seen := links[linkID]
+	links[linkID] = now
+
+	if seen && now.Sub(last) < d.cfg.Window {
+		return "repeat_hit"
+	}
+
+	recent := 0
+	for _, t := range links {
+		if now.Sub(t) < d.cfg.Window {
+			recent++
+		}
+	}
+	if recent >= d.cfg.FanOutLinks {
+		return "link_fan_out"
+	}
+
+	return ""
+}
+
+func (d *scannerDetector) sweep(now time.Time) {
+	for ip, links := range d.hits {
+		for linkID, t := range links {
+			if now.Sub(t) >= d.cfg.Window {
+				delete(links, linkID)
+			}
+		}
+		if len(links) == 0 {
+			delete(d.hits, ip)
+		}
+	}
+	d.lastSweep = now
+}
+
+// detectScanner returns the email scanner heuristic the request trips, if
+// any, and the hit timing seen from its address. A listed gateway range is
+// enough on its own. Timing alone is not: people double-tap links and share
+// addresses behind NAT, so it only counts when the request does not look like
+// a browser navigation either.
+func (s *clickService) detectScanner(req TrackInput, linkID uuid.UUID, now time.Time) (string, string) {
+	timing := s.scanners.observe(req.IP, linkID, now)
+	if s.ipRanges != nil && s.ipRanges.Lookup(req.IP) == IPCategoryEmailScanner {
+		return "gateway_range", timing
+	}
+	if timing != "" {
+		if signal := nonBrowserSignal(req); signal != "" {
+			return timing + " " + signal, timing
+		}
+	}
+	return "", timing
+}
+
+// nonBrowserSignal describes why a request does not look like a browser
+// following a link, or returns an empty string. Gateways fetch links with
+// HTTP libraries or with a browser user agent but not its headers.
+func nonBrowserSignal(req TrackInput) string {
+	if !isBrowserUA(req.UserAgent) {
+		return "non_browser_ua"
+	}
+	if signal := acceptSignal(req); signal != "" {
+		return "accept_header " + signal
+	}
+	if signal := acceptLanguageSignal(req); signal != "" {
+		return "accept_language " + signal
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+	// Campaign timezones are validated and loaded against the same embedded
+	// zone database, whatever the host has installed.
+	_ "time/tzdata"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "project/migrations/sqlc"
+)
+
+var locations sync.Map
+
+// campaignLocation returns the campaign's IANA timezone. CreateCampaign
+// rejects zones that do not load, so an error means the row was written
+// around it. Loaded zones are cached since time.LoadLocation reads the
+// zoneinfo database on every call.
+func campaignLocation(c db.Campaign) (*time.Location, error) {
+	if loc, ok := locations.Load(c.Timezone); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("campaign %s: %w", c.CampaignID, err)
+	}
+
+	locations.Store(c.Timezone, loc)
+	return loc, nil
+}
+
+// localTime interprets a TIMESTAMP column as wall-clock time in loc.
+func localTime(ts pgtype.Timestamp, loc *time.Location) time.Time {
+	t := ts.Time
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// daypart is one entry of a campaign's weekly schedule. End is exclusive and
+// may be "24:00"; an end at or before start wraps past midnight into the
+// following day.
+type daypart struct {
+	Days  []time.Weekday `json:"days"`
+	Start string         `json:"start"`
+	End   string         `json:"end"`
+}
+
+// inSchedule reports whether t, already in the campaign's timezone, falls in
+// one of the schedule's dayparts. An empty schedule is always on.
+func inSchedule(raw []byte, t time.Time) (bool, error) {
+	var parts []daypart
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &parts); err != nil {
+			return false, fmt.Errorf("invalid schedule: %w", err)
+		}
+	}
+	if len(parts) == 0 {
+		return true, nil
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	yesterday := (t.Weekday() + 6) % 7
+
+	for _, p := range parts {
+		start, err := parseClock(p.Start)
+		if err != nil {
+			return false, err
+		}
+		end, err := parseClock(p.End)
+		if err != nil {
+			return false, err
+		}
+
+		if end > start {
+			if slices.Contains(p.Days, t.Weekday()) && minute >= start && minute < end {
+				return true, nil
+			}
+			continue
+		}
+
+		if slices.Contains(p.Days, t.Weekday()) && minute >= start {
+			return true, nil
+		}
+		if slices.Contains(p.Days, yesterday) && minute < end {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// validateSchedule checks every daypart, unlike inSchedule which stops at the
+// first match.
+func validateSchedule(raw []byte) error {
+	var parts []daypart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+
+	for _, p := range parts {
+		for _, d := range p.Days {
+			if d < time.Sunday || d > time.Saturday {
+				return fmt.Errorf("invalid schedule day %d", d)
+			}
+		}
+		if _, err := parseClock(p.Start); err != nil {
+			return err
+		}
+		if _, err := parseClock(p.End); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseClock converts "HH:MM" to minutes after midnight.
+func parseClock(v string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(v, "%d:%d", &h, &m); err != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("invalid schedule time %q", v)
+	}
+	return h*60 + m, nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"time"
+
+	db "project/migrations/sqlc"
+)
+
+// LinkSignature computes the sig parameter for a tracking link. The message
+// is the link ID followed by the form-encoded signed params and, when set,
+// the exp timestamp; params missing from values are signed as empty.
+func LinkSignature(secret, linkID string, signedParams []string, values map[string]string, expires string) string {
+	q := make(url.Values, len(signedParams)+1)
+	for _, name := range signedParams {
+		q.Set(name, values[name])
+	}
+	if expires != "" {
+		q.Set("exp", expires)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(linkID + "?" + q.Encode()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signedValues collects the request values a campaign's signature covers.
+func signedValues(req TrackInput, signedParams []string) map[string]string {
+	values := make(map[string]string, len(signedParams))
+	for _, name := range signedParams {
+		switch name {
+		case "user_id":
+			values[name] = req.UserID
+		case "gaid":
+			values[name] = req.GAID
+		case "idfa":
+			values[name] = req.IDFA
+		default:
+			values[name] = req.Passthrough[name]
+		}
+	}
+	return values
+}
+
+// checkSignature returns an empty string when the request is correctly signed
+// (or the campaign does not sign links), and otherwise the failure reason.
+func checkSignature(campaign db.Campaign, linkID string, req TrackInput, now time.Time) string {
+	if !campaign.SigningSecret.Valid {
+		return ""
+	}
+	if req.Signature == "" {
+		return "unsigned_link"
+	}
+
+	want := LinkSignature(campaign.SigningSecret.String, linkID, campaign.SignedParams, signedValues(req, campaign.SignedParams), req.Expires)
+	if !hmac.Equal([]byte(req.Signature), []byte(want)) {
+		return "bad_signature"
+	}
+
+	if req.Expires != "" {
+		exp, err := strconv.ParseInt(req.Expires, 10, 64)
+		if err != nil || now.Unix() > exp {
+			return "signature_expired"
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "project/migrations/sqlc"
+)
+
+const (
+	slugAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	slugLength   = 7
+	// slugAttempts bounds retries when a generated slug is already taken.
+	slugAttempts = 5
+)
+
+var slugPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,64}$`)
+
+// reservedSlugs could be mistaken for routes or look official; they are
+// compared case-insensitively.
+var reservedSlugs = []string{
+	"admin", "api", "track", "verify", "qr", "static", "assets",
+	"health", "status", "login", "logout", "signup", "help", "support",
+	"www", "mail", "about", "terms", "privacy",
+}
+
+func validateSlug(slug string) error {
+	if !slugPattern.MatchString(slug) {
+		return fmt.Errorf("%w: slug must be 3-64 letters, digits, '-' or '_'", ErrInvalidArgument)
+	}
+	if slices.Contains(reservedSlugs, strings.ToLower(slug)) {
+		return fmt.Errorf("%w: slug %q is reserved", ErrInvalidArgument, slug)
+	}
+	if _, err := uuid.Parse(slug); err == nil {
+		return fmt.Errorf("%w: slug must not be a uuid", ErrInvalidArgument)
+	}
+	return nil
+}
+
+func randomSlug() string {
+	max := big.NewInt(int64(len(slugAlphabet)))
+	b := make([]byte, slugLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(fmt.Sprintf("generating slug: %v", err))
+		}
+		b[i] = slugAlphabet[n.Int64()]
+	}
+	return string(b)
+}
+
+// resolveLinkID accepts either a link UUID or a slug, as the track route and
+// link endpoints do.
+func resolveLinkID(ctx context.Context, q *db.Queries, ref string) (uuid.UUID, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return id, nil
+	}
+	if !slugPattern.MatchString(ref) {
+		return uuid.Nil, fmt.Errorf("%w: invalid link reference", ErrInvalidArgument)
+	}
+	return q.GetLinkIDBySlug(ctx, pgtype.Text{String: ref, Valid: true})
+}
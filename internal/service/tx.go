@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	db "project/migrations/sqlc"
+)
+
+// TxBeginner starts the transactions that multi-statement writes run in;
+// *pgxpool.Pool satisfies it.
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// inTx runs fn with queries bound to a new transaction, committing it when fn
+// succeeds and rolling it back otherwise.
+func inTx(ctx context.Context, pool TxBeginner, queries *db.Queries, fn func(q *db.Queries) error) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
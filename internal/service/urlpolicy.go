@@ -0,0 +1,61 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// URLPolicy restricts where clicks may be sent. It is applied to target and
+// fallback URLs when a campaign is created and again after macro
+// substitution, since macro values come from the tracking request.
+type URLPolicy struct {
+	// AllowedSchemes are the permitted URL schemes, lower case.
+	AllowedSchemes []string
+	// AllowedDomains, when non-empty, limits hosts to these domains and
+	// their subdomains.
+	AllowedDomains []string
+}
+
+var DefaultURLPolicy = URLPolicy{
+	AllowedSchemes: []string{"http", "https"},
+}
+
+func (p URLPolicy) Validate(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	if !slices.Contains(p.AllowedSchemes, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("url scheme %q is not allowed", u.Scheme)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return fmt.Errorf("url has no host")
+	}
+
+	if len(p.AllowedDomains) == 0 {
+		return nil
+	}
+	for _, domain := range p.AllowedDomains {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("url host %q is not in the domain allowlist", host)
+}
+
+var macroPattern = regexp.MustCompile(`\{[A-Za-z0-9_]+\}`)
+
+// ValidateTemplate checks a URL that still contains {macro} placeholders.
+// Each placeholder is replaced with a plain token so the scheme and any
+// literal host are still checked; hosts built from macros can only be
+// checked after substitution.
+func (p URLPolicy) ValidateTemplate(raw string) error {
+	return p.Validate(macroPattern.ReplaceAllString(raw, "macro"))
+}
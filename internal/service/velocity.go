@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type VelocityConfig struct {
+	// MinuteLimit, HourLimit and DayLimit are the clicks one advertising ID
+	// or user_id may make across all campaigns in each window.
+	MinuteLimit int
+	HourLimit   int
+	DayLimit    int
+	// MaxCampaigns is the number of distinct campaigns one advertising ID may
+	// click within a day.
+	MaxCampaigns int
+}
+
+var DefaultVelocityConfig = VelocityConfig{
+	MinuteLimit:  10,
+	HourLimit:    60,
+	DayLimit:     300,
+	MaxCampaigns: 20,
+}
+
+// velocityWindows are the windows counted for every identity, in the order
+// their limits are checked.
+var velocityWindows = []struct {
+	name     string
+	duration time.Duration
+}{
+	{"minute", time.Minute},
+	{"hour", time.Hour},
+	{"day", 24 * time.Hour},
+}
+
+// windowCounter approximates a sliding window count from the current and
+// previous fixed windows, weighting the previous one by how much of it still
+// overlaps the sliding window. It needs no per-click storage.
+type windowCounter struct {
+	start    time.Time
+	current  int
+	previous int
+}
+
+func (w *windowCounter) add(now time.Time, d time.Duration) float64 {
+	start := now.Truncate(d)
+	switch {
+	case start.Equal(w.start):
+	case start.Sub(w.start) == d:
+		w.previous, w.current = w.current, 0
+		w.start = start
+	default:
+		w.previous, w.current = 0, 0
+		w.start = start
+	}
+	w.current++
+
+	overlap := 1 - float64(now.Sub(start))/float64(d)
+	return float64(w.previous)*overlap + float64(w.current)
+}
+
+// identityKeys returns the identities a click is counted under.
+func identityKeys(input TrackInput) []string {
+	keys := make([]string, 0, 3)
+	if input.GAID != "" {
+		keys = append(keys, "gaid "+input.GAID)
+	}
+	if input.IDFA != "" {
+		keys = append(keys, "idfa "+input.IDFA)
+	}
+	if input.UserID != "" {
+		keys = append(keys, "user_id "+input.UserID)
+	}
+	return keys
+}
+
+// maxVelocityIdentities bounds the identities VelocityCheck and
+// CampaignSpreadCheck remember; the least recently seen one is dropped to
+// make room.
+const maxVelocityIdentities = 200000
+
+// VelocityCheck blocks click spamming: a device or user clicking faster than
+// the per-minute, per-hour or per-day limit across all campaigns. Unlike
+// IPRateLimitCheck it counts in memory, so it costs no query per click, and
+// the counts start over when the process restarts.
+type VelocityCheck struct {
+	cfg VelocityConfig
+
+	mu       sync.Mutex
+	counters *lruCache[string, *[3]windowCounter]
+}
+
+func NewVelocityCheck(cfg VelocityConfig) *VelocityCheck {
+	if cfg.MinuteLimit <= 0 {
+		cfg.MinuteLimit = DefaultVelocityConfig.MinuteLimit
+	}
+	if cfg.HourLimit <= 0 {
+		cfg.HourLimit = DefaultVelocityConfig.HourLimit
+	}
+	if cfg.DayLimit <= 0 {
+		cfg.DayLimit = DefaultVelocityConfig.DayLimit
+	}
+	return &VelocityCheck{cfg: cfg, counters: newLRUCache[string, *[3]windowCounter](maxVelocityIdentities)}
+}
+
+func (c *VelocityCheck) Name() string {
+	return "velocity"
+}
+
+func (c *VelocityCheck) Check(ctx context.Context, input TrackInput, clickID string) FraudCheckResult {
+	keys := identityKeys(input)
+	if len(keys) == 0 {
+		return FraudCheckResult{
+			Block:  false,
+			Reason: "velocity: no device id or user_id provided",
+		}
+	}
+
+	if reason := c.observe(keys, time.Now()); reason != "" {
+		return FraudCheckResult{
+			Block:  true,
+			Reason: "velocity: " + reason,
+		}
+	}
+
+	return FraudCheckResult{
+		Block:  false,
+		Reason: "velocity: within click limits",
+	}
+}
+
+// observe counts a click for every key and describes the first limit one of
+// them exceeds, or returns an empty string.
+func (c *VelocityCheck) observe(keys []string, now time.Time) string {
+	limits := [3]int{c.cfg.MinuteLimit, c.cfg.HourLimit, c.cfg.DayLimit}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	exceeded := ""
+	for _, key := range keys {
+		counters, ok := c.counters.get(key)
+		if !ok {
+			counters = &[3]windowCounter{}
+			c.counters.add(key, counters)
+		}
+		for i, window := range velocityWindows {
+			count := counters[i].add(now, window.duration)
+			if exceeded == "" && count > float64(limits[i]) {
+				kind, _, _ := strings.Cut(key, " ")
+				exceeded = fmt.Sprintf("%s made more than %d clicks in a %s", kind, limits[i], window.name)
+			}
+		}
+	}
+	return exceeded
+}
+
+// sweep drops identities that have not clicked for two days, after which
+// all their windows are empty.
+func (c *VelocityCheck) sweep(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	day := velocityWindows[len(velocityWindows)-1].duration
+	c.counters.pruneOldest(func(counters *[3]windowCounter) bool {
+		return now.Sub(counters[len(counters)-1].start) >= 2*day
+	})
+}
+
+// CampaignSpreadCheck blocks advertising IDs that click on more distinct
+// campaigns in a day than a real user plausibly would, which click farms
+// and SDK spoofers do while staying under every per-campaign cap.
+type CampaignSpreadCheck struct {
+	maxCampaigns int
+
+	mu      sync.Mutex
+	devices *lruCache[string, map[uuid.UUID]time.Time]
+}
+
+func NewCampaignSpreadCheck(cfg VelocityConfig) *CampaignSpreadCheck {
+	if cfg.MaxCampaigns <= 0 {
+		cfg.MaxCampaigns = DefaultVelocityConfig.MaxCampaigns
+	}
+	return &CampaignSpreadCheck{maxCampaigns: cfg.MaxCampaigns, devices: newLRUCache[string, map[uuid.UUID]time.Time](maxVelocityIdentities)}
+}
+
+func (c *CampaignSpreadCheck) Name() string {
+	return "campaign_spread"
+}
+
+func (c *CampaignSpreadCheck) Check(ctx context.Context, input TrackInput, clickID string) FraudCheckResult {
+	id := input.GAID
+	if id == "" {
+		id = input.IDFA
+	}
+	campaign, ok := campaignFromContext(ctx)
+	if id == "" || !ok {
+		return FraudCheckResult{
+			Block:  false,
+			Reason: "campaign_spread: no device id provided",
+		}
+	}
+
+	if count := c.observe(id, campaign.CampaignID, time.Now()); count > c.maxCampaigns {
+		return FraudCheckResult{
+			Block:  true,
+			Reason: fmt.Sprintf("campaign_spread: device clicked %d campaigns in a day", count),
+		}
+	}
+
+	return FraudCheckResult{
+		Block:  false,
+		Reason: "campaign_spread: within campaign limit",
+	}
+}
+
+// observe records a click by device on campaignID and returns how many
+// distinct campaigns the device clicked within the last day.
+func (c *CampaignSpreadCheck) observe(device string, campaignID uuid.UUID, now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	campaigns, ok := c.devices.get(device)
+	if !ok {
+		campaigns = make(map[uuid.UUID]time.Time)
+		c.devices.add(device, campaigns)
+	}
+	campaigns[campaignID] = now
+	if len(campaigns) > c.maxCampaigns {
+		pruneCampaigns(campaigns, now)
+	}
+	return len(campaigns)
+}
+
+// sweep drops devices that have not clicked for a day, after which none of
+// their campaigns count.
+func (c *CampaignSpreadCheck) sweep(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.devices.pruneOldest(func(campaigns map[uuid.UUID]time.Time) bool {
+		pruneCampaigns(campaigns, now)
+		return len(campaigns) == 0
+	})
+}
+
+func pruneCampaigns(campaigns map[uuid.UUID]time.Time, now time.Time) {
+	for campaignID, t := range campaigns {
+		if now.Sub(t) >= 24*time.Hour {
+			delete(campaigns, campaignID)
+		}
+	}
+}
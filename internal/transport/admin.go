@@ -0,0 +1,356 @@
+package transport
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"project/internal/endpoints"
+	"project/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+)
+
+func mountAdminRoutes(r chi.Router, a endpoints.AdminEndpointSet) {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(encodeAdminError),
+	}
+
+	r.Method("GET", "/reports/clicks", kithttp.NewServer(
+		a.ClickReportEndpoint,
+		decodeClickReportRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("GET", "/reports/outcomes", kithttp.NewServer(
+		a.OutcomeReportEndpoint,
+		decodeOutcomeReportRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("POST", "/campaigns", kithttp.NewServer(
+		a.CreateCampaignEndpoint,
+		decodeJSONRequest[endpoints.CreateCampaignRequest],
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("POST", "/publishers", kithttp.NewServer(
+		a.CreatePublisherEndpoint,
+		decodeJSONRequest[endpoints.CreatePublisherRequest],
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("GET", "/publishers", kithttp.NewServer(
+		a.ListPublishersEndpoint,
+		kithttp.NopRequestDecoder,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("POST", "/links", kithttp.NewServer(
+		a.CreateTrackingLinkEndpoint,
+		decodeJSONRequest[endpoints.CreateTrackingLinkRequest],
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("POST", "/links/{link_id}/sign", kithttp.NewServer(
+		a.SignLinkEndpoint,
+		decodeSignLinkRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("GET", "/links/{link_id}/qr", kithttp.NewServer(
+		a.LinkQRCodeEndpoint,
+		decodeLinkQRCodeRequest,
+		encodeQRCodeResponse,
+		opts...,
+	))
+
+	r.Method("GET", "/campaigns/{campaign_id}/links", kithttp.NewServer(
+		a.ListTrackingLinksEndpoint,
+		decodeListTrackingLinksRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("PUT", "/publishers/{id}/status", kithttp.NewServer(
+		a.SetPublisherStatusEndpoint,
+		decodeSetStatusRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("PUT", "/links/{id}/status", kithttp.NewServer(
+		a.SetTrackingLinkStatusEndpoint,
+		decodeSetStatusRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("PUT", "/links/{id}/slug", kithttp.NewServer(
+		a.SetTrackingLinkSlugEndpoint,
+		decodeSetSlugRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("GET", "/quality/{scope}", kithttp.NewServer(
+		a.ListQualityScoresEndpoint,
+		decodeListQualityScoresRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("POST", "/clicks/{click_id}/rescore", kithttp.NewServer(
+		a.RescoreClickEndpoint,
+		decodeRescoreClickRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("GET", "/advertisers/{advertiser_id}/invoices/{month}", kithttp.NewServer(
+		a.InvoiceEndpoint,
+		decodeInvoiceRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("POST", "/advertisers", kithttp.NewServer(
+		a.CreateAdvertiserEndpoint,
+		decodeJSONRequest[endpoints.CreateAdvertiserRequest],
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("GET", "/advertisers", kithttp.NewServer(
+		a.ListAdvertisersEndpoint,
+		kithttp.NopRequestDecoder,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+
+	r.Method("PUT", "/campaigns/{campaign_id}/advertiser", kithttp.NewServer(
+		a.SetCampaignAdvertiserEndpoint,
+		decodeSetCampaignAdvertiserRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	))
+}
+
+func requireAdminToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Without a configured token the admin API stays closed.
+			got := r.Header.Get("Authorization")
+			if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte("Bearer "+token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func decodeJSONRequest[T any](_ context.Context, r *http.Request) (any, error) {
+	var req T
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidArgument, err)
+	}
+	return req, nil
+}
+
+func decodeListTrackingLinksRequest(_ context.Context, r *http.Request) (any, error) {
+	return endpoints.ListTrackingLinksRequest{
+		CampaignID: chi.URLParam(r, "campaign_id"),
+	}, nil
+}
+
+func decodeSetStatusRequest(_ context.Context, r *http.Request) (any, error) {
+	var req endpoints.SetStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidArgument, err)
+	}
+	req.ID = chi.URLParam(r, "id")
+	return req, nil
+}
+
+func decodeSignLinkRequest(_ context.Context, r *http.Request) (any, error) {
+	var req endpoints.SignLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidArgument, err)
+	}
+	req.LinkID = chi.URLParam(r, "link_id")
+	return req, nil
+}
+
+// decodeLinkQRCodeRequest reads format, size and ecc from the query; every
+// other query parameter is pre-filled on the encoded tracking URL.
+func decodeLinkQRCodeRequest(_ context.Context, r *http.Request) (any, error) {
+	query := r.URL.Query()
+
+	req := endpoints.LinkQRCodeRequest{
+		LinkID: chi.URLParam(r, "link_id"),
+		Params: make(map[string]string),
+		Format: query.Get("format"),
+		ECC:    query.Get("ecc"),
+	}
+	if v := query.Get("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: size: %v", service.ErrInvalidArgument, err)
+		}
+		req.Size = size
+	}
+
+	for name := range query {
+		switch name {
+		case "format", "size", "ecc":
+		default:
+			req.Params[name] = query.Get(name)
+		}
+	}
+	return req, nil
+}
+
+func encodeQRCodeResponse(_ context.Context, w http.ResponseWriter, response any) error {
+	code := response.(service.QRCode)
+	w.Header().Set("Content-Type", code.ContentType)
+	_, err := w.Write(code.Body)
+	return err
+}
+
+func decodeSetSlugRequest(_ context.Context, r *http.Request) (any, error) {
+	var req endpoints.SetSlugRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, fmt.Errorf("%w: %v", service.ErrInvalidArgument, err)
+		}
+	}
+	req.LinkID = chi.URLParam(r, "id")
+	return req, nil
+}
+
+func decodeListQualityScoresRequest(_ context.Context, r *http.Request) (any, error) {
+	return endpoints.ListQualityScoresRequest{
+		Scope: chi.URLParam(r, "scope"),
+	}, nil
+}
+
+func decodeRescoreClickRequest(_ context.Context, r *http.Request) (any, error) {
+	var req endpoints.RescoreClickRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, fmt.Errorf("%w: %v", service.ErrInvalidArgument, err)
+		}
+	}
+	req.ClickID = chi.URLParam(r, "click_id")
+	return req, nil
+}
+
+func decodeInvoiceRequest(_ context.Context, r *http.Request) (any, error) {
+	return endpoints.InvoiceRequest{
+		AdvertiserID: chi.URLParam(r, "advertiser_id"),
+		Month:        chi.URLParam(r, "month"),
+	}, nil
+}
+
+func decodeSetCampaignAdvertiserRequest(_ context.Context, r *http.Request) (any, error) {
+	var req endpoints.SetCampaignAdvertiserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidArgument, err)
+	}
+	req.CampaignID = chi.URLParam(r, "campaign_id")
+	return req, nil
+}
+
+// decodeClickReportRequest reads the reporting window from the from/to query
+// parameters. Both accept RFC 3339 timestamps or plain dates and default to
+// the last 7 days; to is exclusive.
+func decodeClickReportRequest(_ context.Context, r *http.Request) (any, error) {
+	query := r.URL.Query()
+
+	from, to, err := parseReportWindow(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints.ClickReportRequest{
+		CampaignID: query.Get("campaign_id"),
+		Dimension:  query.Get("dimension"),
+		From:       from,
+		To:         to,
+	}, nil
+}
+
+// decodeOutcomeReportRequest reads the same window as the click report; the
+// campaign_id filter is optional.
+func decodeOutcomeReportRequest(_ context.Context, r *http.Request) (any, error) {
+	query := r.URL.Query()
+
+	from, to, err := parseReportWindow(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints.OutcomeReportRequest{
+		CampaignID: query.Get("campaign_id"),
+		From:       from,
+		To:         to,
+	}, nil
+}
+
+func parseReportWindow(query url.Values) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	if v := query.Get("to"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: to: %v", service.ErrInvalidArgument, err)
+		}
+		to = t
+	}
+
+	from := to.AddDate(0, 0, -7)
+	if v := query.Get("from"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from: %v", service.ErrInvalidArgument, err)
+		}
+		from = t
+	}
+
+	return from, to, nil
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, v)
+}
+
+func encodeAdminError(_ context.Context, err error, w http.ResponseWriter) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidArgument):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrNotFound):
+		status = http.StatusNotFound
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
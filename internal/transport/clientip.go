@@ -0,0 +1,156 @@
+package transport
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// DefaultTrustedProxies are the networks whose forwarding headers are
+// believed when TRUSTED_PROXIES is not configured: loopback and private
+// ranges, where a load balancer in front of the tracker usually lives.
+var DefaultTrustedProxies = []string{
+	"127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16",
+	"::1/128", "fc00::/7",
+}
+
+// ParseTrustedProxies parses CIDRs or bare addresses.
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, err
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, err
+		}
+		if prefix.Addr().Is4In6() {
+			return nil, fmt.Errorf("%s: use the IPv4 form", v)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func isTrusted(trusted []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP resolves the client address of r, and returns the raw forwarding
+// headers it arrived with (empty when there were none).
+//
+// Headers are only believed when the connecting peer is a trusted proxy. The
+// Forwarded chain, or X-Forwarded-For when there is none, is then walked
+// right to left, skipping trusted hops: the first untrusted hop is the
+// client, as anything to its left was written by the client itself.
+// X-Real-IP is used only when neither chain is present.
+func clientIP(r *http.Request, trusted []netip.Prefix) (string, string) {
+	chain := forwardedChain(r)
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, ok := parseAddr(host)
+	if !ok {
+		return "", chain
+	}
+	if !isTrusted(trusted, peer) {
+		return peer.String(), chain
+	}
+
+	var hops []string
+	if v := r.Header.Values("Forwarded"); len(v) > 0 {
+		hops = forwardedFor(strings.Join(v, ","))
+	} else if v := r.Header.Values("X-Forwarded-For"); len(v) > 0 {
+		hops = strings.Split(strings.Join(v, ","), ",")
+	}
+
+	if len(hops) == 0 {
+		if addr, ok := parseAddr(r.Header.Get("X-Real-IP")); ok {
+			return addr.String(), chain
+		}
+		return peer.String(), chain
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseAddr(hops[i])
+		if !ok {
+			// Garbage past the trusted proxies; the nearest hop we could
+			// read is the best answer.
+			break
+		}
+		client = addr
+		if !isTrusted(trusted, addr) {
+			break
+		}
+	}
+	return client.String(), chain
+}
+
+// parseAddr reads one hop, which may carry a port, brackets or quotes, and
+// normalizes it: IPv4-mapped IPv6 becomes IPv4, zones are dropped and IPv6 is
+// printed in its canonical compressed form.
+func parseAddr(v string) (netip.Addr, bool) {
+	v = strings.Trim(strings.TrimSpace(v), `"`)
+	if v == "" {
+		return netip.Addr{}, false
+	}
+
+	if addrPort, err := netip.ParseAddrPort(v); err == nil {
+		return addrPort.Addr().Unmap().WithZone(""), true
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(v, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
+
+// forwardedFor extracts the for= parameter of each element of an RFC 7239
+// Forwarded header. Elements without one keep an empty slot so the chain
+// stays aligned with the proxies that wrote it.
+func forwardedFor(header string) []string {
+	elements := strings.Split(header, ",")
+	hops := make([]string, len(elements))
+	for i, element := range elements {
+		for _, pair := range strings.Split(element, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(name, "for") {
+				hops[i] = value
+			}
+		}
+	}
+	return hops
+}
+
+// forwardedChain records the forwarding headers verbatim, with the peer
+// address, for forensic use.
+func forwardedChain(r *http.Request) string {
+	var lines []string
+	for _, name := range []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"} {
+		for _, v := range r.Header.Values(name) {
+			lines = append(lines, name+": "+v)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(append(lines, "Remote-Addr: "+r.RemoteAddr), "\n")
+}
@@ -2,48 +2,90 @@ package transport
 
 import (
 	"context"
-	"net"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"net/netip"
 
 	"project/internal/endpoints"
+	"project/internal/service"
 
 	"github.com/go-chi/chi/v5"
 	kithttp "github.com/go-kit/kit/transport/http"
 )
 
-func NewHTTPHandler(e endpoints.TrackEndpointSet) http.Handler {
+type Config struct {
+	// PassthroughParams lists the publisher query parameters copied from the
+	// tracking URL onto the click.
+	PassthroughParams []string
+	// AdminToken is required as a bearer token on /admin routes. When it is
+	// empty every admin request is refused.
+	AdminToken string
+	// TrustedProxies are the peers whose Forwarded, X-Forwarded-For and
+	// X-Real-IP headers are believed.
+	TrustedProxies []netip.Prefix
+}
+
+func NewHTTPHandler(e endpoints.TrackEndpointSet, a endpoints.AdminEndpointSet, cfg Config) http.Handler {
 	r := chi.NewRouter()
 
 	r.Method("GET", "/track/{link_id}", kithttp.NewServer(
 		e.TrackEndpoint,
-		decodeTrackRequest,
+		makeDecodeTrackRequest(cfg),
 		encodeTrackResponse,
 	))
 
+	r.Method("POST", "/track/{link_id}/verify", kithttp.NewServer(
+		e.VerifyEndpoint,
+		decodeVerifyRequest,
+		encodeVerifyResponse,
+		kithttp.ServerErrorEncoder(encodeVerifyError),
+	))
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(requireAdminToken(cfg.AdminToken))
+		mountAdminRoutes(r, a)
+	})
+
 	return r
 }
 
-func decodeTrackRequest(_ context.Context, r *http.Request) (any, error) {
-	return endpoints.TrackRequest{
-		LinkID:    chi.URLParam(r, "link_id"),
-		UserID:    r.URL.Query().Get("user_id"),
-		GAID:      r.URL.Query().Get("gaid"),
-		IDFA:      r.URL.Query().Get("idfa"),
-		IP:        getIP(r),
-		UserAgent: r.UserAgent(),
-		Referrer:  r.Referer(),
-	}, nil
+func makeDecodeTrackRequest(cfg Config) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (any, error) {
+		query := r.URL.Query()
+
+		passthrough := make(map[string]string)
+		for _, name := range cfg.PassthroughParams {
+			if v := query.Get(name); v != "" {
+				passthrough[name] = v
+			}
+		}
+
+		ip, chain := clientIP(r, cfg.TrustedProxies)
+
+		return endpoints.TrackRequest{
+			LinkID:         chi.URLParam(r, "link_id"),
+			UserID:         query.Get("user_id"),
+			GAID:           query.Get("gaid"),
+			IDFA:           query.Get("idfa"),
+			IP:             ip,
+			ForwardedChain: chain,
+			UserAgent:      r.UserAgent(),
+			Referrer:       r.Referer(),
+			Passthrough:    passthrough,
+			Signature:      query.Get("sig"),
+			Expires:        query.Get("exp"),
+			Headers:        r.Header.Clone(),
+			Fingerprint:    service.ClickFingerprint(ip, r.Header),
+		}, nil
+	}
 }
 
 func encodeTrackResponse(ctx context.Context, w http.ResponseWriter, resp any) error {
 	r := resp.(endpoints.TrackResponse)
 
-	if r.StatusCode == 302 && r.RedirectURL != "" {
-		w.Header().Set("Location", r.RedirectURL)
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		w.WriteHeader(302)
-		_, err := w.Write([]byte(r.Body))
-		return err
+	if r.RedirectURL != "" {
+		return encodeRedirect(w, r)
 	}
 
 	if len(r.Body) > 0 && r.Body[0] == '<' {
@@ -51,19 +93,65 @@ func encodeTrackResponse(ctx context.Context, w http.ResponseWriter, resp any) e
 	} else {
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	}
-	
+
 	w.WriteHeader(r.StatusCode)
 	_, err := w.Write([]byte(r.Body))
 	return err
 }
 
-func getIP(r *http.Request) string {
-	if x := r.Header.Get("X-Forwarded-For"); x != "" {
-		return x
+// encodeRedirect writes a redirect in the response's redirect mode. Every mode
+// is marked no-store so browsers, which cache 301s indefinitely otherwise,
+// come back through the tracker on the next click.
+func encodeRedirect(w http.ResponseWriter, r endpoints.TrackResponse) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	status := http.StatusFound
+	switch r.RedirectMode {
+	case service.RedirectHTTP301:
+		status = http.StatusMovedPermanently
+	case service.RedirectHTTP307:
+		status = http.StatusTemporaryRedirect
+	case service.RedirectMetaRefresh:
+		status = http.StatusOK
+	case service.RedirectJavaScript, service.RedirectInterstitial:
+		status = http.StatusOK
+		w.Header().Set("Referrer-Policy", "no-referrer")
+	}
+
+	if status != http.StatusOK {
+		w.Header().Set("Location", r.RedirectURL)
+	}
+
+	w.WriteHeader(status)
+	_, err := w.Write([]byte(r.Body))
+	return err
+}
+
+func decodeVerifyRequest(_ context.Context, r *http.Request) (any, error) {
+	var req endpoints.VerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, service.ErrChallengeFailed
 	}
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return ""
+	req.LinkID = chi.URLParam(r, "link_id")
+	return req, nil
+}
+
+func encodeVerifyResponse(ctx context.Context, w http.ResponseWriter, resp any) error {
+	w.Header().Set("Cache-Control", "no-store")
+	return kithttp.EncodeJSONResponse(ctx, w, resp)
+}
+
+// encodeVerifyError answers every failed challenge the same way so the
+// response does not reveal which check failed.
+func encodeVerifyError(_ context.Context, err error, w http.ResponseWriter) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, service.ErrChallengeFailed) {
+		status = http.StatusForbidden
 	}
-	return host
-}
\ No newline at end of file
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": service.ErrChallengeFailed.Error()})
+}
@@ -0,0 +1,55 @@
+-- name: ChargeCampaignBudget :one
+UPDATE campaigns
+SET spent_micros = spent_micros + sqlc.arg(amount_micros)::bigint
+WHERE campaign_id = sqlc.arg(campaign_id)
+  AND (budget_micros IS NULL OR spent_micros + sqlc.arg(amount_micros)::bigint <= budget_micros)
+RETURNING spent_micros, budget_micros;
+
+-- name: RefundCampaignBudget :exec
+UPDATE campaigns
+SET spent_micros = GREATEST(spent_micros - sqlc.arg(amount_micros)::bigint, 0)
+WHERE campaign_id = sqlc.arg(campaign_id);
+
+-- name: InsertLedgerEntry :execrows
+INSERT INTO billing_ledger (
+    entry_id,
+    click_id,
+    campaign_id,
+    advertiser_id,
+    entry_type,
+    amount_micros
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+ON CONFLICT (click_id, entry_type) DO NOTHING;
+
+-- name: GetLedgerCharge :one
+SELECT * FROM billing_ledger
+WHERE click_id = $1 AND entry_type = 'charge'
+LIMIT 1;
+
+-- name: MarkClickFraud :execrows
+UPDATE clicks
+SET status = 'fraud',
+    fraud_check_failed = array_append(fraud_check_failed, sqlc.arg(reason)::text)
+WHERE click_id = sqlc.arg(click_id) AND status = 'allowed';
+
+-- name: InvoiceSummary :many
+SELECT
+    campaign_id,
+    COUNT(*) FILTER (WHERE entry_type = 'charge') AS charged_clicks,
+    COALESCE(SUM(amount_micros) FILTER (WHERE entry_type = 'charge'), 0)::bigint AS charged_micros,
+    COUNT(*) FILTER (WHERE entry_type = 'credit') AS credited_clicks,
+    COALESCE(-SUM(amount_micros) FILTER (WHERE entry_type = 'credit'), 0)::bigint AS credited_micros,
+    COALESCE(SUM(amount_micros), 0)::bigint AS net_micros
+FROM billing_ledger
+WHERE advertiser_id = sqlc.arg(advertiser_id)
+  AND created_at >= sqlc.arg(from_time)
+  AND created_at < sqlc.arg(to_time)
+GROUP BY campaign_id
+ORDER BY campaign_id;
@@ -1,10 +1,58 @@
 -- name: IsBlocked :one
 SELECT EXISTS (
-    SELECT 1 FROM blocked_ids WHERE id = $1
+    SELECT 1 FROM blocked_ids
+    WHERE lower(id) = lower($1)
+      AND (expires_at IS NULL OR expires_at > NOW())
 ) AS blocked;
 
 -- name: InsertBlockedID :exec
 INSERT INTO blocked_ids(id, updated_at)
 VALUES ($1, NOW())
 ON CONFLICT (id) DO UPDATE
-SET updated_at = NOW();
\ No newline at end of file
+SET updated_at = NOW();
+
+-- name: UpsertAutoBlockedID :exec
+INSERT INTO blocked_ids (id, updated_at, reason, source, expires_at)
+VALUES (
+    sqlc.arg(id),
+    NOW(),
+    sqlc.arg(reason),
+    'auto',
+    NOW() + make_interval(secs => sqlc.arg(ttl_seconds)::double precision)
+)
+ON CONFLICT (id) DO UPDATE
+SET updated_at = NOW(), reason = EXCLUDED.reason, expires_at = EXCLUDED.expires_at
+WHERE blocked_ids.source = 'auto';
+
+-- name: DeleteExpiredBlockedIDs :execrows
+DELETE FROM blocked_ids
+WHERE expires_at IS NOT NULL AND expires_at <= NOW();
+
+-- name: FindAbusiveDevices :many
+SELECT
+    device_id::text AS device_id,
+    COUNT(*) AS click_count,
+    COUNT(DISTINCT campaign_id) AS campaign_count
+FROM (
+    SELECT gaid AS device_id, campaign_id FROM clicks
+    WHERE gaid IS NOT NULL AND timestamp >= NOW() - make_interval(secs => sqlc.arg(window_seconds)::double precision)
+    UNION ALL
+    SELECT idfa AS device_id, campaign_id FROM clicks
+    WHERE idfa IS NOT NULL AND timestamp >= NOW() - make_interval(secs => sqlc.arg(window_seconds)::double precision)
+) devices
+GROUP BY device_id
+HAVING COUNT(*) >= sqlc.arg(min_clicks)::bigint;
+
+-- name: FindRateLimitedIPs :many
+SELECT
+    ip_address::text AS ip_address,
+    COUNT(*) AS trip_count
+FROM clicks
+WHERE timestamp >= NOW() - make_interval(secs => sqlc.arg(window_seconds)::double precision)
+  AND ip_address IS NOT NULL
+  AND EXISTS (
+      SELECT 1 FROM unnest(fraud_check_failed) AS reason
+      WHERE reason LIKE 'ip_rate_limit%'
+  )
+GROUP BY ip_address
+HAVING COUNT(*) >= sqlc.arg(min_trips)::bigint;
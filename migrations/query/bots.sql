@@ -0,0 +1,3 @@
+-- name: InsertBotVisit :exec
+INSERT INTO bot_visits (visit_id, link_id, campaign_id, bot, verified, ip_address, user_agent)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
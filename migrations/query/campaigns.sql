@@ -1,4 +1,82 @@
--- name: GetCampaignByLinkID :one
+-- name: CreateCampaign :one
+INSERT INTO campaigns (
+    campaign_id,
+    advertiser_id,
+    name,
+    start_date,
+    end_date,
+    status,
+    target_url,
+    fallback_url,
+    timezone,
+    schedule,
+    daily_cap,
+    total_cap,
+    cap_action,
+    frequency_cap,
+    frequency_window_seconds,
+    dedup_window_seconds,
+    cpc_micros,
+    budget_micros,
+    redirect_mode,
+    signing_secret,
+    signed_params,
+    signature_action,
+    target_platform,
+    allowed_referrer_domains,
+    blocked_referrer_domains,
+    empty_referrer
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16,
+    $17,
+    $18,
+    $19,
+    $20,
+    $21,
+    $22,
+    $23,
+    $24,
+    $25,
+    $26
+)
+RETURNING *;
+
+-- name: GetCampaign :one
 SELECT * FROM campaigns
-WHERE link_id = $1
-LIMIT 1;
\ No newline at end of file
+WHERE campaign_id = $1
+LIMIT 1;
+
+-- name: GetAdvertiser :one
+SELECT * FROM advertisers
+WHERE advertiser_id = $1
+LIMIT 1;
+
+-- name: CreateAdvertiser :one
+INSERT INTO advertisers (advertiser_id, name)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: ListAdvertisers :many
+SELECT * FROM advertisers
+ORDER BY name;
+
+-- name: SetCampaignAdvertiser :one
+UPDATE campaigns
+SET advertiser_id = $2
+WHERE campaign_id = $1
+RETURNING *;
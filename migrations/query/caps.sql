@@ -0,0 +1,21 @@
+-- name: TryIncrementClickCounter :execrows
+INSERT INTO click_counters (scope, scope_id, period, clicks)
+VALUES (sqlc.arg(scope), sqlc.arg(scope_id), sqlc.arg(period), 1)
+ON CONFLICT (scope, scope_id, period) DO UPDATE
+SET clicks = click_counters.clicks + 1
+WHERE click_counters.clicks < sqlc.arg(click_cap)::bigint;
+
+-- name: DecrementClickCounter :exec
+UPDATE click_counters
+SET clicks = clicks - 1
+WHERE scope = $1 AND scope_id = $2 AND period = $3 AND clicks > 0;
+
+-- name: PauseCampaign :exec
+UPDATE campaigns
+SET status = 'paused'
+WHERE campaign_id = $1;
+
+-- name: PauseTrackingLink :exec
+UPDATE tracking_links
+SET status = 'paused', paused_reason = $2
+WHERE link_id = $1;
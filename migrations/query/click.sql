@@ -16,7 +16,13 @@ INSERT INTO clicks (
     geo_country,
     geo_state,
     status,
-    fraud_check_failed
+    fraud_check_failed,
+    passthrough,
+    publisher_id,
+    is_unique,
+    forwarded_chain,
+    limit_ad_tracking,
+    fingerprint
 ) VALUES (
     $1,
     NOW(),
@@ -34,11 +40,34 @@ INSERT INTO clicks (
     $13,
     $14,
     $15,
-    $16
+    $16,
+    $17,
+    $18,
+    $19,
+    $20,
+    $21,
+    $22
 );
 
 -- name: CountClicksByIPInLast60Seconds :one
 SELECT COUNT(*) as click_count
 FROM clicks
 WHERE ip_address = $1
-  AND timestamp > NOW() - INTERVAL '60 seconds';
\ No newline at end of file
+  AND timestamp > NOW() - INTERVAL '60 seconds';
+
+-- name: CountFingerprintIdentities :one
+SELECT COUNT(DISTINCT (user_id, COALESCE(gaid, ''), COALESCE(idfa, ''))) AS identity_count
+FROM clicks
+WHERE fingerprint = sqlc.arg(fingerprint)
+  AND timestamp >= NOW() - make_interval(secs => sqlc.arg(window_seconds)::double precision)
+  AND (user_id, COALESCE(gaid, ''), COALESCE(idfa, '')) <> (sqlc.arg(user_id)::text, sqlc.arg(gaid)::text, sqlc.arg(idfa)::text);
+
+-- name: GetChallengedClick :one
+SELECT click_id, link_id, user_id, gaid, idfa, passthrough, fraud_check_failed
+FROM clicks
+WHERE click_id = $1 AND status = 'challenged';
+
+-- name: CompleteChallenge :execrows
+UPDATE clicks
+SET status = $2, fraud_check_failed = $3
+WHERE click_id = $1 AND status = 'challenged';
@@ -0,0 +1,29 @@
+-- name: TouchCampaignIdentity :one
+INSERT INTO campaign_identities (
+    campaign_id,
+    identity,
+    window_start,
+    window_clicks,
+    dedup_start,
+    last_seen_at
+) VALUES (
+    sqlc.arg(campaign_id),
+    sqlc.arg(identity),
+    NOW(),
+    1,
+    NOW(),
+    NOW()
+)
+ON CONFLICT (campaign_id, identity) DO UPDATE
+SET
+    window_start = CASE
+        WHEN campaign_identities.window_start <= NOW() - make_interval(secs => sqlc.arg(window_seconds)::bigint)
+        THEN NOW() ELSE campaign_identities.window_start END,
+    window_clicks = CASE
+        WHEN campaign_identities.window_start <= NOW() - make_interval(secs => sqlc.arg(window_seconds)::bigint)
+        THEN 1 ELSE campaign_identities.window_clicks + 1 END,
+    dedup_start = CASE
+        WHEN campaign_identities.dedup_start <= NOW() - make_interval(secs => sqlc.arg(dedup_seconds)::bigint)
+        THEN NOW() ELSE campaign_identities.dedup_start END,
+    last_seen_at = NOW()
+RETURNING window_clicks, (dedup_start = NOW())::boolean AS is_unique;
@@ -0,0 +1,53 @@
+-- name: ResolveTrackingLink :one
+SELECT
+    sqlc.embed(tracking_links),
+    sqlc.embed(publishers),
+    sqlc.embed(campaigns)
+FROM tracking_links
+JOIN publishers ON publishers.publisher_id = tracking_links.publisher_id
+JOIN campaigns ON campaigns.campaign_id = tracking_links.campaign_id
+WHERE tracking_links.link_id = $1
+LIMIT 1;
+
+-- name: CreateTrackingLink :one
+INSERT INTO tracking_links (
+    link_id,
+    campaign_id,
+    publisher_id,
+    daily_cap,
+    total_cap,
+    payout_micros,
+    cpc_micros,
+    slug
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING *;
+
+-- name: GetLinkIDBySlug :one
+SELECT link_id FROM tracking_links
+WHERE slug = $1;
+
+-- name: ListTrackingLinksByCampaign :many
+SELECT * FROM tracking_links
+WHERE campaign_id = $1
+ORDER BY created_at;
+
+-- name: SetTrackingLinkStatus :one
+UPDATE tracking_links
+SET status = $2, quality_override = $3, paused_reason = $4
+WHERE link_id = $1
+RETURNING *;
+
+-- name: SetTrackingLinkSlug :one
+UPDATE tracking_links
+SET slug = $2
+WHERE link_id = $1
+RETURNING *;
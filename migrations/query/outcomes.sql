@@ -0,0 +1,14 @@
+-- name: IncrementOutcomeCounter :exec
+INSERT INTO outcome_counters (hour, campaign_id, reason, hits)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (hour, campaign_id, reason) DO UPDATE
+SET hits = outcome_counters.hits + EXCLUDED.hits;
+
+-- name: OutcomeReport :many
+SELECT campaign_id, reason, SUM(hits)::bigint AS hits
+FROM outcome_counters
+WHERE hour >= sqlc.arg(from_time)
+  AND hour < sqlc.arg(to_time)
+  AND (sqlc.narg(campaign_id)::uuid IS NULL OR campaign_id = sqlc.narg(campaign_id))
+GROUP BY campaign_id, reason
+ORDER BY campaign_id, reason;
@@ -0,0 +1,19 @@
+-- name: CreatePublisher :one
+INSERT INTO publishers (publisher_id, name, site_domains)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetPublisher :one
+SELECT * FROM publishers
+WHERE publisher_id = $1
+LIMIT 1;
+
+-- name: ListPublishers :many
+SELECT * FROM publishers
+ORDER BY name;
+
+-- name: SetPublisherStatus :one
+UPDATE publishers
+SET status = $2, quality_override = $3, paused_reason = $4
+WHERE publisher_id = $1
+RETURNING *;
@@ -0,0 +1,70 @@
+-- name: LinkQualityStats :many
+WITH windowed AS (
+    SELECT
+        link_id,
+        publisher_id,
+        user_id,
+        status,
+        fraud_check_failed,
+        timestamp - LAG(timestamp) OVER (
+            PARTITION BY link_id, user_id ORDER BY timestamp
+        ) AS gap
+    FROM clicks
+    WHERE timestamp >= NOW() - make_interval(secs => sqlc.arg(window_seconds)::double precision)
+      AND status <> 'scanner'
+)
+SELECT
+    link_id,
+    publisher_id,
+    COUNT(*) AS total_clicks,
+    COUNT(*) FILTER (WHERE status = 'fraud') AS fraud_clicks,
+    COUNT(*) FILTER (WHERE cardinality(fraud_check_failed) > 0) AS flagged_clicks,
+    (COUNT(*) - COUNT(DISTINCT user_id))::bigint AS duplicate_clicks,
+    COUNT(*) FILTER (WHERE gap < INTERVAL '1 second') AS fast_clicks
+FROM windowed
+GROUP BY link_id, publisher_id;
+
+-- name: InsertQualityScore :exec
+INSERT INTO quality_scores (
+    scope,
+    scope_id,
+    computed_at,
+    window_start,
+    total_clicks,
+    fraud_rate,
+    flagged_rate,
+    duplicate_rate,
+    timing_anomaly_rate,
+    score
+) VALUES (
+    sqlc.arg(scope),
+    sqlc.arg(scope_id),
+    NOW(),
+    NOW() - make_interval(secs => sqlc.arg(window_seconds)::double precision),
+    sqlc.arg(total_clicks),
+    sqlc.arg(fraud_rate),
+    sqlc.arg(flagged_rate),
+    sqlc.arg(duplicate_rate),
+    sqlc.arg(timing_anomaly_rate),
+    sqlc.arg(score)
+);
+
+-- name: ListLatestQualityScores :many
+SELECT DISTINCT ON (scope, scope_id) *
+FROM quality_scores
+WHERE scope = $1
+ORDER BY scope, scope_id, computed_at DESC;
+
+-- name: AutoPausePublisher :execrows
+UPDATE publishers
+SET status = 'paused', paused_reason = $2
+WHERE publisher_id = $1
+  AND status = 'active'
+  AND NOT quality_override;
+
+-- name: AutoPauseTrackingLink :execrows
+UPDATE tracking_links
+SET status = 'paused', paused_reason = $2
+WHERE link_id = $1
+  AND status = 'active'
+  AND NOT quality_override;
@@ -0,0 +1,17 @@
+-- name: ClickReport :many
+SELECT
+    COALESCE(CASE sqlc.arg(dimension)::text
+        WHEN 'publisher_id' THEN publisher_id::text
+        WHEN 'link_id' THEN link_id::text
+        WHEN 'referrer_domain' THEN regexp_replace(regexp_replace(btrim(lower(substring(referrer FROM '^\s*[A-Za-z][A-Za-z0-9+.-]*://(?:[^/?#@]*@)?(\[[^]/?#]*\]|[^/?#:]*)')), '[]'), '\.$', ''), '^www\.', '')
+        ELSE passthrough ->> sqlc.arg(dimension)::text
+    END, '')::text AS dimension_value,
+    status,
+    COUNT(*) AS click_count,
+    COUNT(*) FILTER (WHERE is_unique) AS unique_count
+FROM clicks
+WHERE campaign_id = sqlc.arg(campaign_id)
+  AND timestamp >= sqlc.arg(from_time)
+  AND timestamp < sqlc.arg(to_time)
+GROUP BY dimension_value, status
+ORDER BY dimension_value, status;
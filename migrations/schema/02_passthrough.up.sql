@@ -0,0 +1 @@
+ALTER TABLE clicks ADD COLUMN passthrough JSONB NOT NULL DEFAULT '{}'::jsonb;
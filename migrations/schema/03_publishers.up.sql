@@ -0,0 +1,38 @@
+CREATE TYPE publisher_status AS ENUM ('active', 'paused');
+CREATE TYPE link_status AS ENUM ('active', 'paused');
+
+CREATE TABLE publishers (
+    publisher_id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    status publisher_status NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE tracking_links (
+    link_id UUID PRIMARY KEY,
+    campaign_id UUID NOT NULL REFERENCES campaigns(campaign_id),
+    publisher_id UUID NOT NULL REFERENCES publishers(publisher_id),
+    status link_status NOT NULL DEFAULT 'active',
+    daily_cap BIGINT,
+    total_cap BIGINT,
+    payout_micros BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX tracking_links_campaign_id_idx ON tracking_links (campaign_id);
+CREATE INDEX tracking_links_publisher_id_idx ON tracking_links (publisher_id);
+
+-- Existing campaign links are moved to a house publisher so that URLs
+-- already handed out keep resolving.
+INSERT INTO publishers (publisher_id, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'direct');
+
+INSERT INTO tracking_links (link_id, campaign_id, publisher_id)
+SELECT link_id, campaign_id, '00000000-0000-0000-0000-000000000001'
+FROM campaigns;
+
+ALTER TABLE campaigns DROP COLUMN link_id;
+
+ALTER TABLE clicks ADD COLUMN publisher_id UUID REFERENCES publishers(publisher_id);
+UPDATE clicks SET publisher_id = '00000000-0000-0000-0000-000000000001';
+ALTER TABLE clicks ALTER COLUMN publisher_id SET NOT NULL;
@@ -0,0 +1,23 @@
+CREATE TYPE quality_scope AS ENUM ('publisher', 'link');
+
+ALTER TABLE publishers ADD COLUMN quality_override BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE publishers ADD COLUMN paused_reason TEXT;
+
+ALTER TABLE tracking_links ADD COLUMN quality_override BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE tracking_links ADD COLUMN paused_reason TEXT;
+
+CREATE TABLE quality_scores (
+    scope quality_scope NOT NULL,
+    scope_id UUID NOT NULL,
+    computed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    window_start TIMESTAMP NOT NULL,
+    total_clicks BIGINT NOT NULL,
+    fraud_rate DOUBLE PRECISION NOT NULL,
+    flagged_rate DOUBLE PRECISION NOT NULL,
+    duplicate_rate DOUBLE PRECISION NOT NULL,
+    timing_anomaly_rate DOUBLE PRECISION NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (scope, scope_id, computed_at)
+);
+
+CREATE INDEX clicks_timestamp_idx ON clicks (timestamp);
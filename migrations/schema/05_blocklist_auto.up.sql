@@ -0,0 +1,8 @@
+CREATE TYPE blocklist_source AS ENUM ('manual', 'auto');
+
+ALTER TABLE blocked_ids ADD COLUMN reason TEXT;
+ALTER TABLE blocked_ids ADD COLUMN source blocklist_source NOT NULL DEFAULT 'manual';
+ALTER TABLE blocked_ids ADD COLUMN expires_at TIMESTAMP;
+
+CREATE INDEX clicks_gaid_idx ON clicks (gaid) WHERE gaid IS NOT NULL;
+CREATE INDEX clicks_idfa_idx ON clicks (idfa) WHERE idfa IS NOT NULL;
@@ -0,0 +1,19 @@
+CREATE TYPE cap_action AS ENUM ('unavailable', 'fallback', 'pause');
+CREATE TYPE counter_scope AS ENUM ('campaign', 'link');
+
+ALTER TYPE click_status ADD VALUE 'capped';
+
+ALTER TABLE campaigns ADD COLUMN daily_cap BIGINT;
+ALTER TABLE campaigns ADD COLUMN total_cap BIGINT;
+ALTER TABLE campaigns ADD COLUMN cap_action cap_action NOT NULL DEFAULT 'unavailable';
+ALTER TABLE campaigns ADD COLUMN fallback_url TEXT;
+
+-- Allowed-click counters. period is a UTC date (YYYY-MM-DD) for daily caps
+-- or 'total' for lifetime caps.
+CREATE TABLE click_counters (
+    scope counter_scope NOT NULL,
+    scope_id UUID NOT NULL,
+    period TEXT NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (scope, scope_id, period)
+);
@@ -0,0 +1,17 @@
+ALTER TABLE campaigns ADD COLUMN frequency_cap BIGINT;
+ALTER TABLE campaigns ADD COLUMN frequency_window_seconds BIGINT NOT NULL DEFAULT 86400;
+ALTER TABLE campaigns ADD COLUMN dedup_window_seconds BIGINT NOT NULL DEFAULT 86400;
+
+ALTER TABLE clicks ADD COLUMN is_unique BOOLEAN NOT NULL DEFAULT false;
+
+-- One row per identity (user id or device id, prefixed with its kind) per
+-- campaign, tracking the current frequency window and dedup window.
+CREATE TABLE campaign_identities (
+    campaign_id UUID NOT NULL REFERENCES campaigns(campaign_id),
+    identity TEXT NOT NULL,
+    window_start TIMESTAMP NOT NULL,
+    window_clicks BIGINT NOT NULL,
+    dedup_start TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (campaign_id, identity)
+);
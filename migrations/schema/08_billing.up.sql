@@ -0,0 +1,38 @@
+CREATE TYPE ledger_entry_type AS ENUM ('charge', 'credit');
+
+CREATE TABLE advertisers (
+    advertiser_id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Existing campaigns are assigned to a placeholder advertiser until they are
+-- re-assigned.
+INSERT INTO advertisers (advertiser_id, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'unassigned');
+
+ALTER TABLE campaigns ADD COLUMN advertiser_id UUID REFERENCES advertisers(advertiser_id);
+UPDATE campaigns SET advertiser_id = '00000000-0000-0000-0000-000000000001';
+ALTER TABLE campaigns ALTER COLUMN advertiser_id SET NOT NULL;
+
+-- Money is stored in micros of the account currency.
+ALTER TABLE campaigns ADD COLUMN cpc_micros BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE campaigns ADD COLUMN budget_micros BIGINT;
+ALTER TABLE campaigns ADD COLUMN spent_micros BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE tracking_links ADD COLUMN cpc_micros BIGINT;
+
+-- Charges are positive and credits negative, so SUM(amount_micros) is the
+-- net amount owed.
+CREATE TABLE billing_ledger (
+    entry_id UUID PRIMARY KEY,
+    click_id UUID NOT NULL,
+    campaign_id UUID NOT NULL REFERENCES campaigns(campaign_id),
+    advertiser_id UUID NOT NULL REFERENCES advertisers(advertiser_id),
+    entry_type ledger_entry_type NOT NULL,
+    amount_micros BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (click_id, entry_type)
+);
+
+CREATE INDEX billing_ledger_advertiser_idx ON billing_ledger (advertiser_id, created_at);
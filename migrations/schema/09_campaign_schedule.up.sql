@@ -0,0 +1,7 @@
+-- start_date and end_date are wall-clock times in the campaign's timezone.
+ALTER TABLE campaigns ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+-- Optional weekly dayparting, e.g.
+-- [{"days": [1, 2, 3, 4, 5], "start": "09:00", "end": "21:00"}]
+-- with days numbered from Sunday = 0. An empty list means always on.
+ALTER TABLE campaigns ADD COLUMN schedule JSONB NOT NULL DEFAULT '[]'::jsonb;
@@ -0,0 +1,11 @@
+-- Hourly tally of track request outcomes by reason code, including requests
+-- that never produce a click row (unknown links, paused or expired
+-- campaigns). campaign_id is the nil uuid when the link could not be
+-- resolved.
+CREATE TABLE outcome_counters (
+    hour TIMESTAMP NOT NULL,
+    campaign_id UUID NOT NULL,
+    reason TEXT NOT NULL,
+    hits BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (hour, campaign_id, reason)
+);
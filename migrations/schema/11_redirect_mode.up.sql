@@ -0,0 +1,13 @@
+-- How a tracked click is forwarded to the target URL. The http_* modes send
+-- that status with a Location header; meta_refresh, javascript and
+-- interstitial answer 200 with a page that navigates client-side.
+CREATE TYPE redirect_mode AS ENUM (
+    'http_301',
+    'http_302',
+    'http_307',
+    'meta_refresh',
+    'javascript',
+    'interstitial'
+);
+
+ALTER TABLE campaigns ADD COLUMN redirect_mode redirect_mode NOT NULL DEFAULT 'http_302';
@@ -0,0 +1,3 @@
+-- Borderline clicks are held as 'challenged' until the visitor passes the JS
+-- challenge, at which point they become 'allowed' (or 'capped').
+ALTER TYPE click_status ADD VALUE 'challenged';
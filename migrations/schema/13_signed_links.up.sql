@@ -0,0 +1,9 @@
+CREATE TYPE signature_action AS ENUM ('reject', 'flag');
+
+-- When signing_secret is set, tracking requests must carry a sig parameter:
+-- an HMAC-SHA256 over the link ID, the signed_params and the optional exp
+-- timestamp. signature_action decides whether unsigned or tampered requests
+-- are rejected outright or recorded with a fraud reason.
+ALTER TABLE campaigns ADD COLUMN signing_secret TEXT;
+ALTER TABLE campaigns ADD COLUMN signed_params TEXT[] NOT NULL DEFAULT '{user_id}';
+ALTER TABLE campaigns ADD COLUMN signature_action signature_action NOT NULL DEFAULT 'reject';
@@ -0,0 +1,3 @@
+-- Short slugs resolve to a tracking link on the /track route in place of its
+-- UUID. Links created before slugs existed have none until one is set.
+ALTER TABLE tracking_links ADD COLUMN slug TEXT UNIQUE;
@@ -0,0 +1,3 @@
+-- The raw proxy headers a click arrived with, kept alongside the resolved
+-- ip_address so spoofed or unusual chains can be investigated later.
+ALTER TABLE clicks ADD COLUMN forwarded_chain TEXT;
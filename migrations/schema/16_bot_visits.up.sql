@@ -0,0 +1,15 @@
+-- Hits from link-preview crawlers and search bots. They are answered with a
+-- preview page and never become clicks. verified is set when the bot's
+-- address passed a forward-confirmed reverse DNS check.
+CREATE TABLE bot_visits (
+    visit_id UUID PRIMARY KEY,
+    timestamp TIMESTAMP NOT NULL DEFAULT NOW(),
+    link_id UUID NOT NULL REFERENCES tracking_links(link_id),
+    campaign_id UUID NOT NULL REFERENCES campaigns(campaign_id),
+    bot TEXT NOT NULL,
+    verified BOOLEAN NOT NULL,
+    ip_address TEXT,
+    user_agent TEXT
+);
+
+CREATE INDEX bot_visits_campaign_idx ON bot_visits (campaign_id, timestamp);
@@ -0,0 +1,3 @@
+-- Hits attributed to email security gateways opening links ahead of the
+-- recipient. They are kept for analysis but never charged, capped or scored.
+ALTER TYPE click_status ADD VALUE 'scanner';
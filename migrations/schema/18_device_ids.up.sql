@@ -0,0 +1,6 @@
+-- Clicks from devices that opted out of ad tracking send the all-zero
+-- advertising ID, which is dropped and recorded here instead.
+ALTER TABLE clicks ADD COLUMN limit_ad_tracking BOOLEAN NOT NULL DEFAULT false;
+
+-- Blocklist lookups ignore case.
+CREATE INDEX blocked_ids_lower_idx ON blocked_ids (lower(id));
@@ -0,0 +1,5 @@
+CREATE TYPE target_platform AS ENUM ('any', 'android', 'ios');
+
+-- Clicks whose device IDs or user agent point to another platform than the
+-- campaign targets are flagged by the platform consistency check.
+ALTER TABLE campaigns ADD COLUMN target_platform target_platform NOT NULL DEFAULT 'any';
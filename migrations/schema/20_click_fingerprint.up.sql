@@ -0,0 +1,6 @@
+-- A hash of the click's IP, user agent and browser headers. It stays the
+-- same when a fraudster rotates user_id and device IDs, so bursts of one
+-- fingerprint under many identities can be found.
+ALTER TABLE clicks ADD COLUMN fingerprint TEXT;
+
+CREATE INDEX clicks_fingerprint_idx ON clicks (fingerprint, timestamp) WHERE fingerprint IS NOT NULL;
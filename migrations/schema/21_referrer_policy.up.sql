@@ -0,0 +1,13 @@
+CREATE TYPE empty_referrer_policy AS ENUM ('allow', 'flag');
+
+-- The sites a publisher declared it runs. A click whose referrer is on
+-- another domain, and not allowed by the campaign, fails the referrer check.
+ALTER TABLE publishers ADD COLUMN site_domains TEXT[] NOT NULL DEFAULT '{}';
+
+-- Domains match themselves and their subdomains. Blocked domains fail the
+-- referrer check whatever the publisher declared. Many in-app clicks carry
+-- no referrer, so whether a missing one fails the check is up to the
+-- campaign.
+ALTER TABLE campaigns ADD COLUMN allowed_referrer_domains TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE campaigns ADD COLUMN blocked_referrer_domains TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE campaigns ADD COLUMN empty_referrer empty_referrer_policy NOT NULL DEFAULT 'allow';
@@ -0,0 +1,5 @@
+-- Daily click counters are keyed by the date in the campaign's timezone, not
+-- the UTC date 06_click_caps describes, so daily caps roll over at the
+-- campaign's local midnight.
+COMMENT ON COLUMN click_counters.period IS
+    'Date (YYYY-MM-DD) in the campaign''s timezone for daily caps, or ''total'' for lifetime caps';
@@ -7,8 +7,115 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const deleteExpiredBlockedIDs = `-- name: DeleteExpiredBlockedIDs :execrows
+DELETE FROM blocked_ids
+WHERE expires_at IS NOT NULL AND expires_at <= NOW()
+`
+
+func (q *Queries) DeleteExpiredBlockedIDs(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredBlockedIDs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const findAbusiveDevices = `-- name: FindAbusiveDevices :many
+SELECT
+    device_id::text AS device_id,
+    COUNT(*) AS click_count,
+    COUNT(DISTINCT campaign_id) AS campaign_count
+FROM (
+    SELECT gaid AS device_id, campaign_id FROM clicks
+    WHERE gaid IS NOT NULL AND timestamp >= NOW() - make_interval(secs => $1::double precision)
+    UNION ALL
+    SELECT idfa AS device_id, campaign_id FROM clicks
+    WHERE idfa IS NOT NULL AND timestamp >= NOW() - make_interval(secs => $1::double precision)
+) devices
+GROUP BY device_id
+HAVING COUNT(*) >= $2::bigint
+`
+
+type FindAbusiveDevicesParams struct {
+	WindowSeconds float64 `json:"window_seconds"`
+	MinClicks     int64   `json:"min_clicks"`
+}
+
+type FindAbusiveDevicesRow struct {
+	DeviceID      string `json:"device_id"`
+	ClickCount    int64  `json:"click_count"`
+	CampaignCount int64  `json:"campaign_count"`
+}
+
+func (q *Queries) FindAbusiveDevices(ctx context.Context, arg FindAbusiveDevicesParams) ([]FindAbusiveDevicesRow, error) {
+	rows, err := q.db.Query(ctx, findAbusiveDevices, arg.WindowSeconds, arg.MinClicks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FindAbusiveDevicesRow{}
+	for rows.Next() {
+		var i FindAbusiveDevicesRow
+		if err := rows.Scan(&i.DeviceID, &i.ClickCount, &i.CampaignCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findRateLimitedIPs = `-- name: FindRateLimitedIPs :many
+SELECT
+    ip_address::text AS ip_address,
+    COUNT(*) AS trip_count
+FROM clicks
+WHERE timestamp >= NOW() - make_interval(secs => $1::double precision)
+  AND ip_address IS NOT NULL
+  AND EXISTS (
+      SELECT 1 FROM unnest(fraud_check_failed) AS reason
+      WHERE reason LIKE 'ip_rate_limit%'
+  )
+GROUP BY ip_address
+HAVING COUNT(*) >= $2::bigint
+`
+
+type FindRateLimitedIPsParams struct {
+	WindowSeconds float64 `json:"window_seconds"`
+	MinTrips      int64   `json:"min_trips"`
+}
+
+type FindRateLimitedIPsRow struct {
+	IpAddress string `json:"ip_address"`
+	TripCount int64  `json:"trip_count"`
+}
+
+func (q *Queries) FindRateLimitedIPs(ctx context.Context, arg FindRateLimitedIPsParams) ([]FindRateLimitedIPsRow, error) {
+	rows, err := q.db.Query(ctx, findRateLimitedIPs, arg.WindowSeconds, arg.MinTrips)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FindRateLimitedIPsRow{}
+	for rows.Next() {
+		var i FindRateLimitedIPsRow
+		if err := rows.Scan(&i.IpAddress, &i.TripCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const insertBlockedID = `-- name: InsertBlockedID :exec
 INSERT INTO blocked_ids(id, updated_at)
 VALUES ($1, NOW())
@@ -23,7 +130,9 @@ func (q *Queries) InsertBlockedID(ctx context.Context, id string) error {
 
 const isBlocked = `-- name: IsBlocked :one
 SELECT EXISTS (
-    SELECT 1 FROM blocked_ids WHERE id = $1
+    SELECT 1 FROM blocked_ids
+    WHERE lower(id) = lower($1)
+      AND (expires_at IS NULL OR expires_at > NOW())
 ) AS blocked
 `
 
@@ -33,3 +142,28 @@ func (q *Queries) IsBlocked(ctx context.Context, id string) (bool, error) {
 	err := row.Scan(&blocked)
 	return blocked, err
 }
+
+const upsertAutoBlockedID = `-- name: UpsertAutoBlockedID :exec
+INSERT INTO blocked_ids (id, updated_at, reason, source, expires_at)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    'auto',
+    NOW() + make_interval(secs => $3::double precision)
+)
+ON CONFLICT (id) DO UPDATE
+SET updated_at = NOW(), reason = EXCLUDED.reason, expires_at = EXCLUDED.expires_at
+WHERE blocked_ids.source = 'auto'
+`
+
+type UpsertAutoBlockedIDParams struct {
+	ID         string      `json:"id"`
+	Reason     pgtype.Text `json:"reason"`
+	TtlSeconds float64     `json:"ttl_seconds"`
+}
+
+func (q *Queries) UpsertAutoBlockedID(ctx context.Context, arg UpsertAutoBlockedIDParams) error {
+	_, err := q.db.Exec(ctx, upsertAutoBlockedID, arg.ID, arg.Reason, arg.TtlSeconds)
+	return err
+}
@@ -7,18 +7,273 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const getCampaignByLinkID = `-- name: GetCampaignByLinkID :one
-SELECT campaign_id, name, start_date, end_date, status, target_url, link_id FROM campaigns
-WHERE link_id = $1
+const createAdvertiser = `-- name: CreateAdvertiser :one
+INSERT INTO advertisers (advertiser_id, name)
+VALUES ($1, $2)
+RETURNING advertiser_id, name, created_at
+`
+
+type CreateAdvertiserParams struct {
+	AdvertiserID uuid.UUID `json:"advertiser_id"`
+	Name         string    `json:"name"`
+}
+
+func (q *Queries) CreateAdvertiser(ctx context.Context, arg CreateAdvertiserParams) (Advertiser, error) {
+	row := q.db.QueryRow(ctx, createAdvertiser, arg.AdvertiserID, arg.Name)
+	var i Advertiser
+	err := row.Scan(&i.AdvertiserID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const createCampaign = `-- name: CreateCampaign :one
+INSERT INTO campaigns (
+    campaign_id,
+    advertiser_id,
+    name,
+    start_date,
+    end_date,
+    status,
+    target_url,
+    fallback_url,
+    timezone,
+    schedule,
+    daily_cap,
+    total_cap,
+    cap_action,
+    frequency_cap,
+    frequency_window_seconds,
+    dedup_window_seconds,
+    cpc_micros,
+    budget_micros,
+    redirect_mode,
+    signing_secret,
+    signed_params,
+    signature_action,
+    target_platform,
+    allowed_referrer_domains,
+    blocked_referrer_domains,
+    empty_referrer
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16,
+    $17,
+    $18,
+    $19,
+    $20,
+    $21,
+    $22,
+    $23,
+    $24,
+    $25,
+    $26
+)
+RETURNING campaign_id, name, start_date, end_date, status, target_url, daily_cap, total_cap, cap_action, fallback_url, frequency_cap, frequency_window_seconds, dedup_window_seconds, advertiser_id, cpc_micros, budget_micros, spent_micros, timezone, schedule, redirect_mode, signing_secret, signed_params, signature_action, target_platform, allowed_referrer_domains, blocked_referrer_domains, empty_referrer
+`
+
+type CreateCampaignParams struct {
+	CampaignID             uuid.UUID           `json:"campaign_id"`
+	AdvertiserID           uuid.UUID           `json:"advertiser_id"`
+	Name                   string              `json:"name"`
+	StartDate              pgtype.Timestamp    `json:"start_date"`
+	EndDate                pgtype.Timestamp    `json:"end_date"`
+	Status                 CampaignStatus      `json:"status"`
+	TargetUrl              string              `json:"target_url"`
+	FallbackUrl            pgtype.Text         `json:"fallback_url"`
+	Timezone               string              `json:"timezone"`
+	Schedule               json.RawMessage     `json:"schedule"`
+	DailyCap               pgtype.Int8         `json:"daily_cap"`
+	TotalCap               pgtype.Int8         `json:"total_cap"`
+	CapAction              CapAction           `json:"cap_action"`
+	FrequencyCap           pgtype.Int8         `json:"frequency_cap"`
+	FrequencyWindowSeconds int64               `json:"frequency_window_seconds"`
+	DedupWindowSeconds     int64               `json:"dedup_window_seconds"`
+	CpcMicros              int64               `json:"cpc_micros"`
+	BudgetMicros           pgtype.Int8         `json:"budget_micros"`
+	RedirectMode           RedirectMode        `json:"redirect_mode"`
+	SigningSecret          pgtype.Text         `json:"signing_secret"`
+	SignedParams           []string            `json:"signed_params"`
+	SignatureAction        SignatureAction     `json:"signature_action"`
+	TargetPlatform         TargetPlatform      `json:"target_platform"`
+	AllowedReferrerDomains []string            `json:"allowed_referrer_domains"`
+	BlockedReferrerDomains []string            `json:"blocked_referrer_domains"`
+	EmptyReferrer          EmptyReferrerPolicy `json:"empty_referrer"`
+}
+
+func (q *Queries) CreateCampaign(ctx context.Context, arg CreateCampaignParams) (Campaign, error) {
+	row := q.db.QueryRow(ctx, createCampaign,
+		arg.CampaignID,
+		arg.AdvertiserID,
+		arg.Name,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Status,
+		arg.TargetUrl,
+		arg.FallbackUrl,
+		arg.Timezone,
+		arg.Schedule,
+		arg.DailyCap,
+		arg.TotalCap,
+		arg.CapAction,
+		arg.FrequencyCap,
+		arg.FrequencyWindowSeconds,
+		arg.DedupWindowSeconds,
+		arg.CpcMicros,
+		arg.BudgetMicros,
+		arg.RedirectMode,
+		arg.SigningSecret,
+		arg.SignedParams,
+		arg.SignatureAction,
+		arg.TargetPlatform,
+		arg.AllowedReferrerDomains,
+		arg.BlockedReferrerDomains,
+		arg.EmptyReferrer,
+	)
+	var i Campaign
+	err := row.Scan(
+		&i.CampaignID,
+		&i.Name,
+		&i.StartDate,
+		&i.EndDate,
+		&i.Status,
+		&i.TargetUrl,
+		&i.DailyCap,
+		&i.TotalCap,
+		&i.CapAction,
+		&i.FallbackUrl,
+		&i.FrequencyCap,
+		&i.FrequencyWindowSeconds,
+		&i.DedupWindowSeconds,
+		&i.AdvertiserID,
+		&i.CpcMicros,
+		&i.BudgetMicros,
+		&i.SpentMicros,
+		&i.Timezone,
+		&i.Schedule,
+		&i.RedirectMode,
+		&i.SigningSecret,
+		&i.SignedParams,
+		&i.SignatureAction,
+		&i.TargetPlatform,
+		&i.AllowedReferrerDomains,
+		&i.BlockedReferrerDomains,
+		&i.EmptyReferrer,
+	)
+	return i, err
+}
+
+const getAdvertiser = `-- name: GetAdvertiser :one
+SELECT advertiser_id, name, created_at FROM advertisers
+WHERE advertiser_id = $1
 LIMIT 1
 `
 
-func (q *Queries) GetCampaignByLinkID(ctx context.Context, linkID uuid.UUID) (Campaign, error) {
-	row := q.db.QueryRow(ctx, getCampaignByLinkID, linkID)
+func (q *Queries) GetAdvertiser(ctx context.Context, advertiserID uuid.UUID) (Advertiser, error) {
+	row := q.db.QueryRow(ctx, getAdvertiser, advertiserID)
+	var i Advertiser
+	err := row.Scan(&i.AdvertiserID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const getCampaign = `-- name: GetCampaign :one
+SELECT campaign_id, name, start_date, end_date, status, target_url, daily_cap, total_cap, cap_action, fallback_url, frequency_cap, frequency_window_seconds, dedup_window_seconds, advertiser_id, cpc_micros, budget_micros, spent_micros, timezone, schedule, redirect_mode, signing_secret, signed_params, signature_action, target_platform, allowed_referrer_domains, blocked_referrer_domains, empty_referrer FROM campaigns
+WHERE campaign_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetCampaign(ctx context.Context, campaignID uuid.UUID) (Campaign, error) {
+	row := q.db.QueryRow(ctx, getCampaign, campaignID)
+	var i Campaign
+	err := row.Scan(
+		&i.CampaignID,
+		&i.Name,
+		&i.StartDate,
+		&i.EndDate,
+		&i.Status,
+		&i.TargetUrl,
+		&i.DailyCap,
+		&i.TotalCap,
+		&i.CapAction,
+		&i.FallbackUrl,
+		&i.FrequencyCap,
+		&i.FrequencyWindowSeconds,
+		&i.DedupWindowSeconds,
+		&i.AdvertiserID,
+		&i.CpcMicros,
+		&i.BudgetMicros,
+		&i.SpentMicros,
+		&i.Timezone,
+		&i.Schedule,
+		&i.RedirectMode,
+		&i.SigningSecret,
+		&i.SignedParams,
+		&i.SignatureAction,
+		&i.TargetPlatform,
+		&i.AllowedReferrerDomains,
+		&i.BlockedReferrerDomains,
+		&i.EmptyReferrer,
+	)
+	return i, err
+}
+
+const listAdvertisers = `-- name: ListAdvertisers :many
+SELECT advertiser_id, name, created_at FROM advertisers
+ORDER BY name
+`
+
+func (q *Queries) ListAdvertisers(ctx context.Context) ([]Advertiser, error) {
+	rows, err := q.db.Query(ctx, listAdvertisers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Advertiser{}
+	for rows.Next() {
+		var i Advertiser
+		if err := rows.Scan(&i.AdvertiserID, &i.Name, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setCampaignAdvertiser = `-- name: SetCampaignAdvertiser :one
+UPDATE campaigns
+SET advertiser_id = $2
+WHERE campaign_id = $1
+RETURNING campaign_id, name, start_date, end_date, status, target_url, daily_cap, total_cap, cap_action, fallback_url, frequency_cap, frequency_window_seconds, dedup_window_seconds, advertiser_id, cpc_micros, budget_micros, spent_micros, timezone, schedule, redirect_mode, signing_secret, signed_params, signature_action, target_platform, allowed_referrer_domains, blocked_referrer_domains, empty_referrer
+`
+
+type SetCampaignAdvertiserParams struct {
+	CampaignID   uuid.UUID `json:"campaign_id"`
+	AdvertiserID uuid.UUID `json:"advertiser_id"`
+}
+
+func (q *Queries) SetCampaignAdvertiser(ctx context.Context, arg SetCampaignAdvertiserParams) (Campaign, error) {
+	row := q.db.QueryRow(ctx, setCampaignAdvertiser, arg.CampaignID, arg.AdvertiserID)
 	var i Campaign
 	err := row.Scan(
 		&i.CampaignID,
@@ -27,7 +282,27 @@ func (q *Queries) GetCampaignByLinkID(ctx context.Context, linkID uuid.UUID) (Ca
 		&i.EndDate,
 		&i.Status,
 		&i.TargetUrl,
-		&i.LinkID,
+		&i.DailyCap,
+		&i.TotalCap,
+		&i.CapAction,
+		&i.FallbackUrl,
+		&i.FrequencyCap,
+		&i.FrequencyWindowSeconds,
+		&i.DedupWindowSeconds,
+		&i.AdvertiserID,
+		&i.CpcMicros,
+		&i.BudgetMicros,
+		&i.SpentMicros,
+		&i.Timezone,
+		&i.Schedule,
+		&i.RedirectMode,
+		&i.SigningSecret,
+		&i.SignedParams,
+		&i.SignatureAction,
+		&i.TargetPlatform,
+		&i.AllowedReferrerDomains,
+		&i.BlockedReferrerDomains,
+		&i.EmptyReferrer,
 	)
 	return i, err
 }
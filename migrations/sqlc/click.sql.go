@@ -7,11 +7,32 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const completeChallenge = `-- name: CompleteChallenge :execrows
+UPDATE clicks
+SET status = $2, fraud_check_failed = $3
+WHERE click_id = $1 AND status = 'challenged'
+`
+
+type CompleteChallengeParams struct {
+	ClickID          uuid.UUID   `json:"click_id"`
+	Status           ClickStatus `json:"status"`
+	FraudCheckFailed []string    `json:"fraud_check_failed"`
+}
+
+func (q *Queries) CompleteChallenge(ctx context.Context, arg CompleteChallengeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, completeChallenge, arg.ClickID, arg.Status, arg.FraudCheckFailed)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const countClicksByIPInLast60Seconds = `-- name: CountClicksByIPInLast60Seconds :one
 SELECT COUNT(*) as click_count
 FROM clicks
@@ -26,6 +47,66 @@ func (q *Queries) CountClicksByIPInLast60Seconds(ctx context.Context, ipAddress
 	return click_count, err
 }
 
+const countFingerprintIdentities = `-- name: CountFingerprintIdentities :one
+SELECT COUNT(DISTINCT (user_id, COALESCE(gaid, ''), COALESCE(idfa, ''))) AS identity_count
+FROM clicks
+WHERE fingerprint = $1
+  AND timestamp >= NOW() - make_interval(secs => $2::double precision)
+  AND (user_id, COALESCE(gaid, ''), COALESCE(idfa, '')) <> ($3::text, $4::text, $5::text)
+`
+
+type CountFingerprintIdentitiesParams struct {
+	Fingerprint   pgtype.Text `json:"fingerprint"`
+	WindowSeconds float64     `json:"window_seconds"`
+	UserID        string      `json:"user_id"`
+	Gaid          string      `json:"gaid"`
+	Idfa          string      `json:"idfa"`
+}
+
+func (q *Queries) CountFingerprintIdentities(ctx context.Context, arg CountFingerprintIdentitiesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countFingerprintIdentities,
+		arg.Fingerprint,
+		arg.WindowSeconds,
+		arg.UserID,
+		arg.Gaid,
+		arg.Idfa,
+	)
+	var identity_count int64
+	err := row.Scan(&identity_count)
+	return identity_count, err
+}
+
+const getChallengedClick = `-- name: GetChallengedClick :one
+SELECT click_id, link_id, user_id, gaid, idfa, passthrough, fraud_check_failed
+FROM clicks
+WHERE click_id = $1 AND status = 'challenged'
+`
+
+type GetChallengedClickRow struct {
+	ClickID          uuid.UUID       `json:"click_id"`
+	LinkID           uuid.UUID       `json:"link_id"`
+	UserID           string          `json:"user_id"`
+	Gaid             pgtype.Text     `json:"gaid"`
+	Idfa             pgtype.Text     `json:"idfa"`
+	Passthrough      json.RawMessage `json:"passthrough"`
+	FraudCheckFailed []string        `json:"fraud_check_failed"`
+}
+
+func (q *Queries) GetChallengedClick(ctx context.Context, clickID uuid.UUID) (GetChallengedClickRow, error) {
+	row := q.db.QueryRow(ctx, getChallengedClick, clickID)
+	var i GetChallengedClickRow
+	err := row.Scan(
+		&i.ClickID,
+		&i.LinkID,
+		&i.UserID,
+		&i.Gaid,
+		&i.Idfa,
+		&i.Passthrough,
+		&i.FraudCheckFailed,
+	)
+	return i, err
+}
+
 const insertClick = `-- name: InsertClick :exec
 INSERT INTO clicks (
     click_id, 
@@ -44,7 +125,13 @@ INSERT INTO clicks (
     geo_country,
     geo_state,
     status,
-    fraud_check_failed
+    fraud_check_failed,
+    passthrough,
+    publisher_id,
+    is_unique,
+    forwarded_chain,
+    limit_ad_tracking,
+    fingerprint
 ) VALUES (
     $1,
     NOW(),
@@ -62,27 +149,39 @@ INSERT INTO clicks (
     $13,
     $14,
     $15,
-    $16
+    $16,
+    $17,
+    $18,
+    $19,
+    $20,
+    $21,
+    $22
 )
 `
 
 type InsertClickParams struct {
-	ClickID          uuid.UUID   `json:"click_id"`
-	LinkID           uuid.UUID   `json:"link_id"`
-	CampaignID       uuid.UUID   `json:"campaign_id"`
-	UserID           string      `json:"user_id"`
-	IpAddress        pgtype.Text `json:"ip_address"`
-	UserAgent        pgtype.Text `json:"user_agent"`
-	Referrer         pgtype.Text `json:"referrer"`
-	Device           pgtype.Text `json:"device"`
-	DeviceModel      pgtype.Text `json:"device_model"`
-	Browser          pgtype.Text `json:"browser"`
-	Gaid             pgtype.Text `json:"gaid"`
-	Idfa             pgtype.Text `json:"idfa"`
-	GeoCountry       pgtype.Text `json:"geo_country"`
-	GeoState         pgtype.Text `json:"geo_state"`
-	Status           ClickStatus `json:"status"`
-	FraudCheckFailed []string    `json:"fraud_check_failed"`
+	ClickID          uuid.UUID       `json:"click_id"`
+	LinkID           uuid.UUID       `json:"link_id"`
+	CampaignID       uuid.UUID       `json:"campaign_id"`
+	UserID           string          `json:"user_id"`
+	IpAddress        pgtype.Text     `json:"ip_address"`
+	UserAgent        pgtype.Text     `json:"user_agent"`
+	Referrer         pgtype.Text     `json:"referrer"`
+	Device           pgtype.Text     `json:"device"`
+	DeviceModel      pgtype.Text     `json:"device_model"`
+	Browser          pgtype.Text     `json:"browser"`
+	Gaid             pgtype.Text     `json:"gaid"`
+	Idfa             pgtype.Text     `json:"idfa"`
+	GeoCountry       pgtype.Text     `json:"geo_country"`
+	GeoState         pgtype.Text     `json:"geo_state"`
+	Status           ClickStatus     `json:"status"`
+	FraudCheckFailed []string        `json:"fraud_check_failed"`
+	Passthrough      json.RawMessage `json:"passthrough"`
+	PublisherID      uuid.UUID       `json:"publisher_id"`
+	IsUnique         bool            `json:"is_unique"`
+	ForwardedChain   pgtype.Text     `json:"forwarded_chain"`
+	LimitAdTracking  bool            `json:"limit_ad_tracking"`
+	Fingerprint      pgtype.Text     `json:"fingerprint"`
 }
 
 func (q *Queries) InsertClick(ctx context.Context, arg InsertClickParams) error {
@@ -103,6 +202,12 @@ func (q *Queries) InsertClick(ctx context.Context, arg InsertClickParams) error
 		arg.GeoState,
 		arg.Status,
 		arg.FraudCheckFailed,
+		arg.Passthrough,
+		arg.PublisherID,
+		arg.IsUnique,
+		arg.ForwardedChain,
+		arg.LimitAdTracking,
+		arg.Fingerprint,
 	)
 	return err
 }
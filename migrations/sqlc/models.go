@@ -6,12 +6,55 @@ package db
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type BlocklistSource string
+
+const (
+	BlocklistSourceManual BlocklistSource = "manual"
+	BlocklistSourceAuto   BlocklistSource = "auto"
+)
+
+func (e *BlocklistSource) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = BlocklistSource(s)
+	case string:
+		*e = BlocklistSource(s)
+	default:
+		return fmt.Errorf("unsupported scan type for BlocklistSource: %T", src)
+	}
+	return nil
+}
+
+type NullBlocklistSource struct {
+	BlocklistSource BlocklistSource `json:"blocklist_source"`
+	Valid           bool            `json:"valid"` // Valid is true if BlocklistSource is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullBlocklistSource) Scan(value interface{}) error {
+	if value == nil {
+		ns.BlocklistSource, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.BlocklistSource.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullBlocklistSource) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.BlocklistSource), nil
+}
+
 type CampaignStatus string
 
 const (
@@ -54,12 +97,58 @@ func (ns NullCampaignStatus) Value() (driver.Value, error) {
 	return string(ns.CampaignStatus), nil
 }
 
+type CapAction string
+
+const (
+	CapActionUnavailable CapAction = "unavailable"
+	CapActionFallback    CapAction = "fallback"
+	CapActionPause       CapAction = "pause"
+)
+
+func (e *CapAction) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = CapAction(s)
+	case string:
+		*e = CapAction(s)
+	default:
+		return fmt.Errorf("unsupported scan type for CapAction: %T", src)
+	}
+	return nil
+}
+
+type NullCapAction struct {
+	CapAction CapAction `json:"cap_action"`
+	Valid     bool      `json:"valid"` // Valid is true if CapAction is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullCapAction) Scan(value interface{}) error {
+	if value == nil {
+		ns.CapAction, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.CapAction.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullCapAction) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.CapAction), nil
+}
+
 type ClickStatus string
 
 const (
-	ClickStatusAllowed ClickStatus = "allowed"
-	ClickStatusFraud   ClickStatus = "fraud"
-	ClickStatusError   ClickStatus = "error"
+	ClickStatusAllowed    ClickStatus = "allowed"
+	ClickStatusFraud      ClickStatus = "fraud"
+	ClickStatusError      ClickStatus = "error"
+	ClickStatusCapped     ClickStatus = "capped"
+	ClickStatusChallenged ClickStatus = "challenged"
+	ClickStatusScanner    ClickStatus = "scanner"
 )
 
 func (e *ClickStatus) Scan(src interface{}) error {
@@ -97,19 +186,461 @@ func (ns NullClickStatus) Value() (driver.Value, error) {
 	return string(ns.ClickStatus), nil
 }
 
+type CounterScope string
+
+const (
+	CounterScopeCampaign CounterScope = "campaign"
+	CounterScopeLink     CounterScope = "link"
+)
+
+func (e *CounterScope) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = CounterScope(s)
+	case string:
+		*e = CounterScope(s)
+	default:
+		return fmt.Errorf("unsupported scan type for CounterScope: %T", src)
+	}
+	return nil
+}
+
+type NullCounterScope struct {
+	CounterScope CounterScope `json:"counter_scope"`
+	Valid        bool         `json:"valid"` // Valid is true if CounterScope is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullCounterScope) Scan(value interface{}) error {
+	if value == nil {
+		ns.CounterScope, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.CounterScope.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullCounterScope) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.CounterScope), nil
+}
+
+type EmptyReferrerPolicy string
+
+const (
+	EmptyReferrerPolicyAllow EmptyReferrerPolicy = "allow"
+	EmptyReferrerPolicyFlag  EmptyReferrerPolicy = "flag"
+)
+
+func (e *EmptyReferrerPolicy) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = EmptyReferrerPolicy(s)
+	case string:
+		*e = EmptyReferrerPolicy(s)
+	default:
+		return fmt.Errorf("unsupported scan type for EmptyReferrerPolicy: %T", src)
+	}
+	return nil
+}
+
+type NullEmptyReferrerPolicy struct {
+	EmptyReferrerPolicy EmptyReferrerPolicy `json:"empty_referrer_policy"`
+	Valid               bool                `json:"valid"` // Valid is true if EmptyReferrerPolicy is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullEmptyReferrerPolicy) Scan(value interface{}) error {
+	if value == nil {
+		ns.EmptyReferrerPolicy, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.EmptyReferrerPolicy.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullEmptyReferrerPolicy) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.EmptyReferrerPolicy), nil
+}
+
+type LedgerEntryType string
+
+const (
+	LedgerEntryTypeCharge LedgerEntryType = "charge"
+	LedgerEntryTypeCredit LedgerEntryType = "credit"
+)
+
+func (e *LedgerEntryType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LedgerEntryType(s)
+	case string:
+		*e = LedgerEntryType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LedgerEntryType: %T", src)
+	}
+	return nil
+}
+
+type NullLedgerEntryType struct {
+	LedgerEntryType LedgerEntryType `json:"ledger_entry_type"`
+	Valid           bool            `json:"valid"` // Valid is true if LedgerEntryType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLedgerEntryType) Scan(value interface{}) error {
+	if value == nil {
+		ns.LedgerEntryType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LedgerEntryType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLedgerEntryType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LedgerEntryType), nil
+}
+
+type LinkStatus string
+
+const (
+	LinkStatusActive LinkStatus = "active"
+	LinkStatusPaused LinkStatus = "paused"
+)
+
+func (e *LinkStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LinkStatus(s)
+	case string:
+		*e = LinkStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LinkStatus: %T", src)
+	}
+	return nil
+}
+
+type NullLinkStatus struct {
+	LinkStatus LinkStatus `json:"link_status"`
+	Valid      bool       `json:"valid"` // Valid is true if LinkStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLinkStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.LinkStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LinkStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLinkStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LinkStatus), nil
+}
+
+type PublisherStatus string
+
+const (
+	PublisherStatusActive PublisherStatus = "active"
+	PublisherStatusPaused PublisherStatus = "paused"
+)
+
+func (e *PublisherStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PublisherStatus(s)
+	case string:
+		*e = PublisherStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PublisherStatus: %T", src)
+	}
+	return nil
+}
+
+type NullPublisherStatus struct {
+	PublisherStatus PublisherStatus `json:"publisher_status"`
+	Valid           bool            `json:"valid"` // Valid is true if PublisherStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPublisherStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.PublisherStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PublisherStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPublisherStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PublisherStatus), nil
+}
+
+type QualityScope string
+
+const (
+	QualityScopePublisher QualityScope = "publisher"
+	QualityScopeLink      QualityScope = "link"
+)
+
+func (e *QualityScope) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = QualityScope(s)
+	case string:
+		*e = QualityScope(s)
+	default:
+		return fmt.Errorf("unsupported scan type for QualityScope: %T", src)
+	}
+	return nil
+}
+
+type NullQualityScope struct {
+	QualityScope QualityScope `json:"quality_scope"`
+	Valid        bool         `json:"valid"` // Valid is true if QualityScope is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullQualityScope) Scan(value interface{}) error {
+	if value == nil {
+		ns.QualityScope, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.QualityScope.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullQualityScope) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.QualityScope), nil
+}
+
+type RedirectMode string
+
+const (
+	RedirectModeHttp301      RedirectMode = "http_301"
+	RedirectModeHttp302      RedirectMode = "http_302"
+	RedirectModeHttp307      RedirectMode = "http_307"
+	RedirectModeMetaRefresh  RedirectMode = "meta_refresh"
+	RedirectModeJavascript   RedirectMode = "javascript"
+	RedirectModeInterstitial RedirectMode = "interstitial"
+)
+
+func (e *RedirectMode) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RedirectMode(s)
+	case string:
+		*e = RedirectMode(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RedirectMode: %T", src)
+	}
+	return nil
+}
+
+type NullRedirectMode struct {
+	RedirectMode RedirectMode `json:"redirect_mode"`
+	Valid        bool         `json:"valid"` // Valid is true if RedirectMode is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullRedirectMode) Scan(value interface{}) error {
+	if value == nil {
+		ns.RedirectMode, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.RedirectMode.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullRedirectMode) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.RedirectMode), nil
+}
+
+type SignatureAction string
+
+const (
+	SignatureActionReject SignatureAction = "reject"
+	SignatureActionFlag   SignatureAction = "flag"
+)
+
+func (e *SignatureAction) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SignatureAction(s)
+	case string:
+		*e = SignatureAction(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SignatureAction: %T", src)
+	}
+	return nil
+}
+
+type NullSignatureAction struct {
+	SignatureAction SignatureAction `json:"signature_action"`
+	Valid           bool            `json:"valid"` // Valid is true if SignatureAction is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullSignatureAction) Scan(value interface{}) error {
+	if value == nil {
+		ns.SignatureAction, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.SignatureAction.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullSignatureAction) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.SignatureAction), nil
+}
+
+type TargetPlatform string
+
+const (
+	TargetPlatformAny     TargetPlatform = "any"
+	TargetPlatformAndroid TargetPlatform = "android"
+	TargetPlatformIos     TargetPlatform = "ios"
+)
+
+func (e *TargetPlatform) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TargetPlatform(s)
+	case string:
+		*e = TargetPlatform(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TargetPlatform: %T", src)
+	}
+	return nil
+}
+
+type NullTargetPlatform struct {
+	TargetPlatform TargetPlatform `json:"target_platform"`
+	Valid          bool           `json:"valid"` // Valid is true if TargetPlatform is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTargetPlatform) Scan(value interface{}) error {
+	if value == nil {
+		ns.TargetPlatform, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TargetPlatform.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTargetPlatform) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TargetPlatform), nil
+}
+
+type Advertiser struct {
+	AdvertiserID uuid.UUID        `json:"advertiser_id"`
+	Name         string           `json:"name"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+}
+
+type BillingLedger struct {
+	EntryID      uuid.UUID        `json:"entry_id"`
+	ClickID      uuid.UUID        `json:"click_id"`
+	CampaignID   uuid.UUID        `json:"campaign_id"`
+	AdvertiserID uuid.UUID        `json:"advertiser_id"`
+	EntryType    LedgerEntryType  `json:"entry_type"`
+	AmountMicros int64            `json:"amount_micros"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+}
+
 type BlockedID struct {
 	ID        string           `json:"id"`
 	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+	Reason    pgtype.Text      `json:"reason"`
+	Source    BlocklistSource  `json:"source"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
 }
 
-type Campaign struct {
-	CampaignID uuid.UUID        `json:"campaign_id"`
-	Name       string           `json:"name"`
-	StartDate  pgtype.Timestamp `json:"start_date"`
-	EndDate    pgtype.Timestamp `json:"end_date"`
-	Status     CampaignStatus   `json:"status"`
-	TargetUrl  string           `json:"target_url"`
+type BotVisit struct {
+	VisitID    uuid.UUID        `json:"visit_id"`
+	Timestamp  pgtype.Timestamp `json:"timestamp"`
 	LinkID     uuid.UUID        `json:"link_id"`
+	CampaignID uuid.UUID        `json:"campaign_id"`
+	Bot        string           `json:"bot"`
+	Verified   bool             `json:"verified"`
+	IpAddress  pgtype.Text      `json:"ip_address"`
+	UserAgent  pgtype.Text      `json:"user_agent"`
+}
+
+type Campaign struct {
+	CampaignID             uuid.UUID           `json:"campaign_id"`
+	Name                   string              `json:"name"`
+	StartDate              pgtype.Timestamp    `json:"start_date"`
+	EndDate                pgtype.Timestamp    `json:"end_date"`
+	Status                 CampaignStatus      `json:"status"`
+	TargetUrl              string              `json:"target_url"`
+	DailyCap               pgtype.Int8         `json:"daily_cap"`
+	TotalCap               pgtype.Int8         `json:"total_cap"`
+	CapAction              CapAction           `json:"cap_action"`
+	FallbackUrl            pgtype.Text         `json:"fallback_url"`
+	FrequencyCap           pgtype.Int8         `json:"frequency_cap"`
+	FrequencyWindowSeconds int64               `json:"frequency_window_seconds"`
+	DedupWindowSeconds     int64               `json:"dedup_window_seconds"`
+	AdvertiserID           uuid.UUID           `json:"advertiser_id"`
+	CpcMicros              int64               `json:"cpc_micros"`
+	BudgetMicros           pgtype.Int8         `json:"budget_micros"`
+	SpentMicros            int64               `json:"spent_micros"`
+	Timezone               string              `json:"timezone"`
+	Schedule               json.RawMessage     `json:"schedule"`
+	RedirectMode           RedirectMode        `json:"redirect_mode"`
+	SigningSecret          pgtype.Text         `json:"signing_secret"`
+	SignedParams           []string            `json:"signed_params"`
+	SignatureAction        SignatureAction     `json:"signature_action"`
+	TargetPlatform         TargetPlatform      `json:"target_platform"`
+	AllowedReferrerDomains []string            `json:"allowed_referrer_domains"`
+	BlockedReferrerDomains []string            `json:"blocked_referrer_domains"`
+	EmptyReferrer          EmptyReferrerPolicy `json:"empty_referrer"`
+}
+
+type CampaignIdentity struct {
+	CampaignID   uuid.UUID        `json:"campaign_id"`
+	Identity     string           `json:"identity"`
+	WindowStart  pgtype.Timestamp `json:"window_start"`
+	WindowClicks int64            `json:"window_clicks"`
+	DedupStart   pgtype.Timestamp `json:"dedup_start"`
+	LastSeenAt   pgtype.Timestamp `json:"last_seen_at"`
 }
 
 type Click struct {
@@ -130,4 +661,62 @@ type Click struct {
 	GeoState         pgtype.Text      `json:"geo_state"`
 	Status           ClickStatus      `json:"status"`
 	FraudCheckFailed []string         `json:"fraud_check_failed"`
+	Passthrough      json.RawMessage  `json:"passthrough"`
+	PublisherID      uuid.UUID        `json:"publisher_id"`
+	IsUnique         bool             `json:"is_unique"`
+	ForwardedChain   pgtype.Text      `json:"forwarded_chain"`
+	LimitAdTracking  bool             `json:"limit_ad_tracking"`
+	Fingerprint      pgtype.Text      `json:"fingerprint"`
+}
+
+type ClickCounter struct {
+	Scope   CounterScope `json:"scope"`
+	ScopeID uuid.UUID    `json:"scope_id"`
+	Period  string       `json:"period"`
+	Clicks  int64        `json:"clicks"`
+}
+
+type OutcomeCounter struct {
+	Hour       pgtype.Timestamp `json:"hour"`
+	CampaignID uuid.UUID        `json:"campaign_id"`
+	Reason     string           `json:"reason"`
+	Hits       int64            `json:"hits"`
+}
+
+type Publisher struct {
+	PublisherID     uuid.UUID        `json:"publisher_id"`
+	Name            string           `json:"name"`
+	Status          PublisherStatus  `json:"status"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	QualityOverride bool             `json:"quality_override"`
+	PausedReason    pgtype.Text      `json:"paused_reason"`
+	SiteDomains     []string         `json:"site_domains"`
+}
+
+type QualityScore struct {
+	Scope             QualityScope     `json:"scope"`
+	ScopeID           uuid.UUID        `json:"scope_id"`
+	ComputedAt        pgtype.Timestamp `json:"computed_at"`
+	WindowStart       pgtype.Timestamp `json:"window_start"`
+	TotalClicks       int64            `json:"total_clicks"`
+	FraudRate         float64          `json:"fraud_rate"`
+	FlaggedRate       float64          `json:"flagged_rate"`
+	DuplicateRate     float64          `json:"duplicate_rate"`
+	TimingAnomalyRate float64          `json:"timing_anomaly_rate"`
+	Score             float64          `json:"score"`
+}
+
+type TrackingLink struct {
+	LinkID          uuid.UUID        `json:"link_id"`
+	CampaignID      uuid.UUID        `json:"campaign_id"`
+	PublisherID     uuid.UUID        `json:"publisher_id"`
+	Status          LinkStatus       `json:"status"`
+	DailyCap        pgtype.Int8      `json:"daily_cap"`
+	TotalCap        pgtype.Int8      `json:"total_cap"`
+	PayoutMicros    int64            `json:"payout_micros"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	QualityOverride bool             `json:"quality_override"`
+	PausedReason    pgtype.Text      `json:"paused_reason"`
+	CpcMicros       pgtype.Int8      `json:"cpc_micros"`
+	Slug            pgtype.Text      `json:"slug"`
 }
@@ -12,11 +12,53 @@ import (
 )
 
 type Querier interface {
+	AutoPausePublisher(ctx context.Context, arg AutoPausePublisherParams) (int64, error)
+	AutoPauseTrackingLink(ctx context.Context, arg AutoPauseTrackingLinkParams) (int64, error)
+	ChargeCampaignBudget(ctx context.Context, arg ChargeCampaignBudgetParams) (ChargeCampaignBudgetRow, error)
+	ClickReport(ctx context.Context, arg ClickReportParams) ([]ClickReportRow, error)
+	CompleteChallenge(ctx context.Context, arg CompleteChallengeParams) (int64, error)
 	CountClicksByIPInLast60Seconds(ctx context.Context, ipAddress pgtype.Text) (int64, error)
-	GetCampaignByLinkID(ctx context.Context, linkID uuid.UUID) (Campaign, error)
+	CountFingerprintIdentities(ctx context.Context, arg CountFingerprintIdentitiesParams) (int64, error)
+	CreateAdvertiser(ctx context.Context, arg CreateAdvertiserParams) (Advertiser, error)
+	CreateCampaign(ctx context.Context, arg CreateCampaignParams) (Campaign, error)
+	CreatePublisher(ctx context.Context, arg CreatePublisherParams) (Publisher, error)
+	CreateTrackingLink(ctx context.Context, arg CreateTrackingLinkParams) (TrackingLink, error)
+	DecrementClickCounter(ctx context.Context, arg DecrementClickCounterParams) error
+	DeleteExpiredBlockedIDs(ctx context.Context) (int64, error)
+	FindAbusiveDevices(ctx context.Context, arg FindAbusiveDevicesParams) ([]FindAbusiveDevicesRow, error)
+	FindRateLimitedIPs(ctx context.Context, arg FindRateLimitedIPsParams) ([]FindRateLimitedIPsRow, error)
+	GetAdvertiser(ctx context.Context, advertiserID uuid.UUID) (Advertiser, error)
+	GetCampaign(ctx context.Context, campaignID uuid.UUID) (Campaign, error)
+	GetChallengedClick(ctx context.Context, clickID uuid.UUID) (GetChallengedClickRow, error)
+	GetLedgerCharge(ctx context.Context, clickID uuid.UUID) (BillingLedger, error)
+	GetLinkIDBySlug(ctx context.Context, slug pgtype.Text) (uuid.UUID, error)
+	GetPublisher(ctx context.Context, publisherID uuid.UUID) (Publisher, error)
+	IncrementOutcomeCounter(ctx context.Context, arg IncrementOutcomeCounterParams) error
 	InsertBlockedID(ctx context.Context, id string) error
+	InsertBotVisit(ctx context.Context, arg InsertBotVisitParams) error
 	InsertClick(ctx context.Context, arg InsertClickParams) error
+	InsertLedgerEntry(ctx context.Context, arg InsertLedgerEntryParams) (int64, error)
+	InsertQualityScore(ctx context.Context, arg InsertQualityScoreParams) error
+	InvoiceSummary(ctx context.Context, arg InvoiceSummaryParams) ([]InvoiceSummaryRow, error)
 	IsBlocked(ctx context.Context, id string) (bool, error)
+	LinkQualityStats(ctx context.Context, windowSeconds float64) ([]LinkQualityStatsRow, error)
+	ListAdvertisers(ctx context.Context) ([]Advertiser, error)
+	ListLatestQualityScores(ctx context.Context, scope QualityScope) ([]QualityScore, error)
+	ListPublishers(ctx context.Context) ([]Publisher, error)
+	ListTrackingLinksByCampaign(ctx context.Context, campaignID uuid.UUID) ([]TrackingLink, error)
+	MarkClickFraud(ctx context.Context, arg MarkClickFraudParams) (int64, error)
+	OutcomeReport(ctx context.Context, arg OutcomeReportParams) ([]OutcomeReportRow, error)
+	PauseCampaign(ctx context.Context, campaignID uuid.UUID) error
+	PauseTrackingLink(ctx context.Context, arg PauseTrackingLinkParams) error
+	RefundCampaignBudget(ctx context.Context, arg RefundCampaignBudgetParams) error
+	ResolveTrackingLink(ctx context.Context, linkID uuid.UUID) (ResolveTrackingLinkRow, error)
+	SetCampaignAdvertiser(ctx context.Context, arg SetCampaignAdvertiserParams) (Campaign, error)
+	SetPublisherStatus(ctx context.Context, arg SetPublisherStatusParams) (Publisher, error)
+	SetTrackingLinkSlug(ctx context.Context, arg SetTrackingLinkSlugParams) (TrackingLink, error)
+	SetTrackingLinkStatus(ctx context.Context, arg SetTrackingLinkStatusParams) (TrackingLink, error)
+	TouchCampaignIdentity(ctx context.Context, arg TouchCampaignIdentityParams) (TouchCampaignIdentityRow, error)
+	TryIncrementClickCounter(ctx context.Context, arg TryIncrementClickCounterParams) (int64, error)
+	UpsertAutoBlockedID(ctx context.Context, arg UpsertAutoBlockedIDParams) error
 }
 
 var _ Querier = (*Queries)(nil)
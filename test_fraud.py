@@ -1,5 +1,6 @@
 import sys
 import time
+import uuid
 import requests
 from concurrent.futures import ThreadPoolExecutor, as_completed
 from typing import List, Dict
@@ -26,8 +27,8 @@ def make_request(
     url = f"{server_url}/track/{link_id}"
     params = {
         "user_id": user_id,
-        "gaid": f"test-gaid-{request_num}",
-        "idfa": f"test-idfa-{request_num}",
+        "gaid": str(uuid.uuid4()),
+        "idfa": str(uuid.uuid4()),
     }
     headers = {
         "User-Agent": user_agent,